@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+// chooseAudiences fills opt.Audiences with audience IDs. Values given with
+// --audience may be IDs or names and are resolved against the account's
+// audiences; without --audience the user may pick from the list when
+// askOptional allows it.
+func chooseAudiences(s *session) error {
+	opt := s.opt
+	if len(opt.Audiences) == 0 && (!askOptional(opt) ||
+		!ui.PromptYesNo("Restrict the label to audiences (pilot rollout)?", false)) {
+		return nil
+	}
+
+	list, err := fetchAudiences(s)
+	if err != nil {
+		return err
+	}
+	if len(opt.Audiences) > 0 {
+		ids, err := resolveAudiences(list, opt.Audiences)
+		if err != nil {
+			return err
+		}
+		opt.Audiences = ids
+		return nil
+	}
+
+	if len(list) == 0 {
+		ui.Info("No audiences available to this account")
+		return nil
+	}
+	texts := make([]string, 0, len(list))
+	for _, a := range list {
+		texts = append(texts, describeAudience(a))
+	}
+	idxs, err := cli.PromptIndexSelection("Select audiences", texts, true, true)
+	if err != nil {
+		return err
+	}
+	opt.Audiences = opt.Audiences[:0]
+	for _, i := range idxs {
+		opt.Audiences = append(opt.Audiences, list[i].ID)
+	}
+	return nil
+}
+
+func fetchAudiences(s *session) ([]shippinglabel.Audience, error) {
+	token, err := s.Token()
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]any
+	err = ui.Spin("Fetching audiences...", func() error {
+		var e error
+		items, e = devcenter.ListAudiences(s.ctx, s.client, token)
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shippinglabel.DecodeAudiences(items)
+}
+
+// resolveAudiences maps each value to an audience ID, matching the ID, name
+// or audienceName case-insensitively.
+func resolveAudiences(list []shippinglabel.Audience, wanted []string) ([]string, error) {
+	var ids, unknown []string
+	for _, w := range wanted {
+		w = strings.TrimSpace(w)
+		found := ""
+		for _, a := range list {
+			if strings.EqualFold(w, a.ID) || strings.EqualFold(w, a.Name) || strings.EqualFold(w, a.AudienceName) {
+				found = a.ID
+				break
+			}
+		}
+		if found == "" {
+			unknown = append(unknown, w)
+			continue
+		}
+		ids = append(ids, found)
+	}
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(list))
+		for _, a := range list {
+			names = append(names, support.FirstNonEmpty(a.AudienceName, a.Name, a.ID))
+		}
+		return nil, support.NewAPIError(fmt.Sprintf("未知的 audience: %s（可用: %s）",
+			strings.Join(unknown, ", "), strings.Join(names, ", ")))
+	}
+	return ids, nil
+}
+
+func describeAudience(a shippinglabel.Audience) string {
+	text := support.FirstNonEmpty(a.AudienceName, a.Name, a.ID)
+	if a.ID != "" && a.ID != text {
+		text += "  [" + a.ID + "]"
+	}
+	if !support.IsBlank(a.Description) {
+		text += " - " + a.Description
+	}
+	return text
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"WU/internal/cli"
+	"WU/internal/terminal"
+)
+
+func TestOptionalQuestionsSkippedForFlaggedOrPipedRuns(t *testing.T) {
+	color.NoColor = true
+	for _, tc := range []struct {
+		name        string
+		opt         cli.CLIOptions
+		interactive bool
+		asked       bool
+	}{
+		{"flagged run at a terminal", cli.CLIOptions{Chids: []string{"{6a0b0d53-0a1c-5e6a-a7a0-1b1d2c3d4e5f}"}}, true, false},
+		{"CHIDs file at a terminal", cli.CLIOptions{CHIDsFile: "chids.txt"}, true, false},
+		{"piped input", cli.CLIOptions{}, false, false},
+		{"interactive run", cli.CLIOptions{}, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := terminal.NewVirtual(100, 10, "n\nn\n")
+			v.SetInteractive(tc.interactive)
+			defer terminal.Use(v)()
+
+			opt := tc.opt
+			promptInServiceRange(&opt)
+			if err := chooseAudiences(&session{opt: &opt}); err != nil {
+				t.Fatal(err)
+			}
+
+			screen := v.Screen()
+			asked := strings.Contains(screen, "floor/ceiling") && strings.Contains(screen, "audiences")
+			if asked != tc.asked || (!tc.asked && strings.TrimSpace(screen) != "") {
+				t.Errorf("asked = %v, want %v; screen:\n%s", asked, tc.asked, screen)
+			}
+			if opt.FloorOS != "" || opt.CeilingOS != "" || len(opt.Audiences) != 0 {
+				t.Errorf("options changed: floor %q ceiling %q audiences %q", opt.FloorOS, opt.CeilingOS, opt.Audiences)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"WU/internal/chid"
+	"WU/internal/cli"
+	"WU/internal/format"
+	"WU/internal/support"
+	"WU/internal/ui"
+	"WU/internal/validate"
+)
+
+const chidUsage = `Usage:
+  wu chid compute [--smbios <file> | --dmidecode <file>] [fields] [--format text|json]
+  wu chid add <project> [--chids <guid...>] [--import <file>]
+  wu chid add --import <file.csv>
+  wu chid list [<project>]
+  wu chid find <chid | text>
+
+compute derives the CHIDs (HardwareID-0 .. 14) of a machine the way
+ComputerHardwareIds.exe does, without running it on Windows. SMBIOS values
+come from a raw table dump, dmidecode output, or explicit fields; explicit
+fields override values read from a file. Without any of them the local
+table at /sys/firmware/dmi/tables/DMI is read.
+
+  --smbios <file>        Raw SMBIOS table (Linux DMI table or Windows RSMB dump)
+  --dmidecode <file>     Text output of dmidecode
+  --manufacturer <text>  System manufacturer
+  --family <text>        System family
+  --product-name <text>  System product name
+  --sku <text>           System SKU number
+  --baseboard-manufacturer <text>
+  --baseboard-product <text>
+  --bios-vendor <text>
+  --bios-version <text>
+  --bios-release <x.y>   BIOS major.minor release, e.g. 1.31
+  --enclosure-type <n>   Chassis type number or name, e.g. 10 or Notebook
+  --format text|json     Output format (default: text)
+
+IDs whose fields are missing are skipped.
+
+add, list and find manage the local CHID registry, which maps machine or
+project names to their CHIDs. The registry is used by the Step 4 CHID
+prompt, where projects can be picked by name. --import reads
+ComputerHardwareIds.exe output (into <project>) or a CSV of
+project,chid[,fields] rows.
+
+  --chid-registry <file> Registry file (default: chids.json next to wu)
+  --import <file>        ComputerHardwareIds.exe output or .csv file`
+
+func runCHID(opt *cli.CLIOptions) int {
+	switch subcommand(opt, 1) {
+	case "compute":
+		return runCHIDCompute(opt)
+	case "add":
+		return runCHIDAdd(opt)
+	case "list":
+		return runCHIDList(opt)
+	case "find":
+		return runCHIDFind(opt)
+	default:
+		fmt.Println(chidUsage)
+		return 2
+	}
+}
+
+func runCHIDCompute(opt *cli.CLIOptions) int {
+	if err := checkFormat(opt.Format, "text", "json"); err != nil {
+		printErr(err)
+		return 2
+	}
+	f, err := loadSMBIOSFields(opt)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	ids := chid.Compute(f)
+
+	if opt.Format == "json" {
+		if ids == nil {
+			ids = []chid.HardwareID{}
+		}
+		os.Stdout.Write(append(format.MustJSONIndent(map[string]any{"fields": f, "hardwareIds": ids}), '\n'))
+		return 0
+	}
+
+	ui.Info("Computer Information")
+	for _, kv := range [][2]string{
+		{"BIOS Vendor", f.BIOSVendor},
+		{"BIOS Version", f.BIOSVersion},
+		{"BIOS Major Release", f.BIOSMajorRelease},
+		{"BIOS Minor Release", f.BIOSMinorRelease},
+		{"Manufacturer", f.Manufacturer},
+		{"Family", f.Family},
+		{"ProductName", f.ProductName},
+		{"SKUNumber", f.SKUNumber},
+		{"EnclosureType", f.EnclosureType},
+		{"BaseboardManufacturer", f.BaseboardManufacturer},
+		{"BaseboardProduct", f.BaseboardProduct},
+	} {
+		fmt.Printf("  %-22s %s\n", kv[0]+":", support.Or(kv[1], "(missing)"))
+	}
+
+	if len(ids) == 0 {
+		ui.Warn("No CHIDs: Manufacturer is missing")
+		return 1
+	}
+	ui.Ok(fmt.Sprintf("%d hardware ID(s)", len(ids)))
+	for _, id := range ids {
+		fmt.Printf("  {%s}   <-- %s\n", id.GUID, id.Description())
+	}
+	return 0
+}
+
+// loadSMBIOSFields reads --smbios / --dmidecode (or the local DMI table
+// when nothing is given) and applies the explicit field flags on top.
+func loadSMBIOSFields(opt *cli.CLIOptions) (chid.Fields, error) {
+	var f chid.Fields
+	x := opt.SMBIOS
+	explicit := x != (cli.SMBIOSFields{})
+
+	switch {
+	case !support.IsBlank(opt.SMBIOSFile) && !support.IsBlank(opt.DmidecodeFile):
+		return f, support.NewAPIError("--smbios 与 --dmidecode 只能指定一个")
+	case !support.IsBlank(opt.DmidecodeFile):
+		b, err := os.ReadFile(opt.DmidecodeFile)
+		if err != nil {
+			return f, support.NewAPIError("读取 dmidecode 输出失败: " + err.Error())
+		}
+		if f, err = chid.ParseDmidecode(string(b)); err != nil {
+			return f, err
+		}
+	case !support.IsBlank(opt.SMBIOSFile) || !explicit:
+		path := support.Or(opt.SMBIOSFile, chid.DefaultSMBIOSPath)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return f, support.NewAPIError("读取 SMBIOS 表失败: " + err.Error())
+		}
+		if f, err = chid.ParseSMBIOS(b); err != nil {
+			return f, err
+		}
+	}
+
+	set := func(dst *string, v string) {
+		if !support.IsBlank(v) {
+			*dst = strings.TrimSpace(v)
+		}
+	}
+	set(&f.Manufacturer, x.Manufacturer)
+	set(&f.Family, x.Family)
+	set(&f.ProductName, x.ProductName)
+	set(&f.SKUNumber, x.SKUNumber)
+	set(&f.BaseboardManufacturer, x.BaseboardManufacturer)
+	set(&f.BaseboardProduct, x.BaseboardProduct)
+	set(&f.BIOSVendor, x.BIOSVendor)
+	set(&f.BIOSVersion, x.BIOSVersion)
+
+	if v := strings.TrimSpace(x.BIOSRelease); v != "" {
+		major, minor, ok := strings.Cut(v, ".")
+		a, err1 := strconv.Atoi(major)
+		b, err2 := strconv.Atoi(minor)
+		if !ok || err1 != nil || err2 != nil || a < 0 || a > 255 || b < 0 || b > 255 {
+			return f, support.NewAPIError("--bios-release 需要 major.minor 格式（如 1.31），但输入为: " + v)
+		}
+		f.BIOSMajorRelease = chid.FormatRelease(a)
+		f.BIOSMinorRelease = chid.FormatRelease(b)
+	}
+	if v := strings.TrimSpace(x.EnclosureType); v != "" {
+		n, ok := chid.ChassisType(v)
+		if !ok {
+			return f, support.NewAPIError("--enclosure-type 无法识别: " + v)
+		}
+		f.EnclosureType = chid.FormatEnclosure(n)
+	}
+	return f, nil
+}
+
+// chidRegistryPath is --chid-registry, or chids.json next to the executable.
+func chidRegistryPath(opt *cli.CLIOptions) string {
+	if !support.IsBlank(opt.CHIDRegistry) {
+		return opt.CHIDRegistry
+	}
+	return filepath.Join(filepath.Dir(credentialPath()), "chids.json")
+}
+
+func runCHIDAdd(opt *cli.CLIOptions) int {
+	path := chidRegistryPath(opt)
+	reg, err := chid.LoadRegistry(path)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	project := strings.TrimSpace(subcommand(opt, 2))
+
+	// project name -> entries, in the order they were given
+	var names []string
+	byName := map[string][]chid.Entry{}
+	add := func(name string, entries ...chid.Entry) {
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], entries...)
+	}
+
+	if !support.IsBlank(opt.Import) {
+		b, err := os.ReadFile(opt.Import)
+		if err != nil {
+			printErr(support.NewAPIError("读取导入文件失败: " + err.Error()))
+			return 1
+		}
+		if strings.EqualFold(filepath.Ext(opt.Import), ".csv") {
+			csvNames, csvEntries, err := chid.ParseCSV(strings.NewReader(string(b)))
+			if err != nil {
+				printErr(err)
+				return exitCode(err)
+			}
+			for _, n := range csvNames {
+				add(n, csvEntries[n]...)
+			}
+		} else {
+			entries := chid.ParseHardwareIDsText(string(b))
+			if len(entries) == 0 {
+				ui.Fail("No CHIDs found in " + opt.Import)
+				return 1
+			}
+			if project == "" {
+				ui.Fail("project name is required when importing ComputerHardwareIds output")
+				return 2
+			}
+			add(project, entries...)
+		}
+	}
+	if len(opt.Chids) > 0 {
+		if project == "" {
+			ui.Fail("project name is required with --chids")
+			return 2
+		}
+		guids, err := validate.NormalizeCHIDsRequired(opt.Chids)
+		if err != nil {
+			printErr(err)
+			return 2
+		}
+		for _, g := range guids {
+			add(project, chid.Entry{CHID: g})
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println(chidUsage)
+		return 2
+	}
+
+	for _, n := range names {
+		added := reg.Add(n, byName[n])
+		ui.Ok(fmt.Sprintf("%s: %d new CHID(s), %d total", n, added, len(reg.Project(n).CHIDs)))
+	}
+	if err := reg.Save(path); err != nil {
+		ui.Fail("Failed to save CHID registry: " + err.Error())
+		return 1
+	}
+	ui.EndLine("Saved " + path)
+	return 0
+}
+
+func runCHIDList(opt *cli.CLIOptions) int {
+	path := chidRegistryPath(opt)
+	reg, err := chid.LoadRegistry(path)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	if name := subcommand(opt, 2); name != "" {
+		p := reg.Project(name)
+		if p == nil {
+			ui.Fail("Project not in the CHID registry: " + name)
+			return 1
+		}
+		printCHIDProject(*p)
+		return 0
+	}
+	if len(reg.Projects) == 0 {
+		ui.Info("CHID registry is empty: " + path)
+		return 0
+	}
+	ui.Ok(fmt.Sprintf("%d project(s) in %s", len(reg.Projects), path))
+	for _, p := range reg.Projects {
+		ui.Item(fmt.Sprintf("%s  (%d CHIDs)", p.Name, len(p.CHIDs)))
+	}
+	return 0
+}
+
+func runCHIDFind(opt *cli.CLIOptions) int {
+	query := subcommand(opt, 2)
+	if support.IsBlank(query) {
+		fmt.Println(chidUsage)
+		return 2
+	}
+	reg, err := chid.LoadRegistry(chidRegistryPath(opt))
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	found := reg.Find(query)
+	if len(found) == 0 {
+		ui.Info("No project matches " + query)
+		return 1
+	}
+	for _, p := range found {
+		printCHIDProject(p)
+	}
+	return 0
+}
+
+func printCHIDProject(p chid.Project) {
+	ui.Item(fmt.Sprintf("%s  (%d CHIDs)", p.Name, len(p.CHIDs)))
+	for _, e := range p.CHIDs {
+		if e.Fields != "" {
+			fmt.Printf("    {%s}   <-- %s\n", e.CHID, e.Fields)
+		} else {
+			fmt.Printf("    {%s}\n", e.CHID)
+		}
+	}
+}
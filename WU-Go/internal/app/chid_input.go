@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"WU/internal/cli"
+	"WU/internal/support"
+	"WU/internal/ui"
+	"WU/internal/validate"
+)
+
+// loadCHIDsFile appends the CHIDs found in --chids-file to opt.Chids,
+// reporting every line that was rejected.
+func loadCHIDsFile(opt *cli.CLIOptions) error {
+	if support.IsBlank(opt.CHIDsFile) {
+		return nil
+	}
+	b, err := os.ReadFile(opt.CHIDsFile)
+	if err != nil {
+		return support.NewAPIError("读取 --chids-file 失败: " + err.Error())
+	}
+	ex := validate.ExtractCHIDs(string(b))
+	reportExtractedCHIDs(opt.CHIDsFile, ex)
+	if len(ex.CHIDs) == 0 {
+		return support.NewAPIError("--chids-file 中没有合法的 CHID: " + opt.CHIDsFile)
+	}
+	opt.Chids = append(opt.Chids, ex.CHIDs...)
+	return nil
+}
+
+// reportExtractedCHIDs prints how many CHIDs were read and each rejected
+// line with its reason.
+func reportExtractedCHIDs(source string, ex validate.ExtractedCHIDs) {
+	msg := fmt.Sprintf("%d CHID(s) read", len(ex.CHIDs))
+	if source != "" {
+		msg += " from " + source
+	}
+	if ex.Duplicates > 0 {
+		msg += fmt.Sprintf(" (%d duplicate(s) dropped)", ex.Duplicates)
+	}
+	ui.Info(msg)
+	for _, r := range ex.Rejected {
+		ui.Warn(fmt.Sprintf("line %d rejected: %s: %s", r.Line, r.Reason, r.Text))
+	}
+}
+
+// startsCHIDBlock reports whether a prompt answer looks like the first line
+// of a pasted block (ComputerHardwareIds output or one GUID per line)
+// rather than a complete comma-separated list.
+func startsCHIDBlock(line string) bool {
+	if strings.Contains(line, "{") || strings.Contains(line, "<-") {
+		return true
+	}
+	return len(validate.ExtractCHIDs(line).CHIDs) == 0
+}
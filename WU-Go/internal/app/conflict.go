@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/drivermeta"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/terminal"
+	"WU/internal/ui"
+)
+
+// conflictChecker compares new labels with the labels on every submission
+// of the product. Driver versions are looked up per submission on demand.
+type conflictChecker struct {
+	sess     *session
+	token    string
+	versions map[string]map[string]string // submissionID -> DriverKey -> version
+}
+
+// checkConflicts warns when a selected PnP ID + CHID pair is already
+// targeted by another label of the product, and asks whether to go on;
+// so does a failed lookup, which is not taken as "no conflicts". Without a
+// terminal it fails unless --allow-conflicts is set. known holds
+// the driver versions of the current submission when already parsed.
+// Returns 0 to continue.
+func checkConflicts(sess *session, bodies []*shippinglabel.Request, known map[string]string) int {
+	opt := sess.opt
+	token, err := sess.Token()
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	cc := &conflictChecker{sess: sess, token: token, versions: map[string]map[string]string{}}
+	if known != nil {
+		cc.versions[opt.SubmissionID] = known
+	}
+
+	var existing []shippinglabel.ExistingLabel
+	err = ui.Spin("Checking labels across the product...", func() error {
+		var e error
+		existing, e = cc.existingLabels(bodies)
+		return e
+	})
+	if err != nil {
+		ui.Warn("Conflict check failed: " + err.Error())
+		return confirmAnyway(opt, "Conflict check failed (non-interactive); pass --allow-conflicts to create without it",
+			"Create the label(s) without the conflict check?")
+	}
+
+	conflicts := shippinglabel.FindConflicts(bodies, existing)
+	if len(conflicts) == 0 {
+		ui.Ok(fmt.Sprintf("No conflicts with %d existing label(s)", len(existing)))
+		return 0
+	}
+
+	for _, c := range conflicts {
+		cc.report(c)
+	}
+	return confirmAnyway(opt, "Conflicting labels found (non-interactive); pass --allow-conflicts to create anyway",
+		"Create the label(s) anyway?")
+}
+
+// confirmAnyway decides whether to go on after conflicts were found or
+// could not be checked: --allow-conflicts goes on, a user at a terminal is
+// asked, and otherwise it fails with refusal. Returns 0 to continue.
+func confirmAnyway(opt *cli.CLIOptions, refusal, question string) int {
+	if opt.AllowConflicts {
+		ui.Warn("--allow-conflicts: continuing")
+		return 0
+	}
+	if !terminal.Current().Interactive() {
+		ui.Fail(refusal)
+		return 1
+	}
+	if !ui.PromptYesNo(question, false) {
+		ui.EndLine("Cancelled")
+		return 1
+	}
+	return 0
+}
+
+// existingLabels lists the labels of all submissions of the product,
+// leaving out labels of the current submission that share a name with a new
+// label: those are being resumed, not competed with.
+func (cc *conflictChecker) existingLabels(bodies []*shippinglabel.Request) ([]shippinglabel.ExistingLabel, error) {
+	opt := cc.sess.opt
+	subs, err := devcenter.ListSubmissions(cc.sess.ctx, cc.sess.client, cc.token, opt.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, b := range bodies {
+		names[labelKey(b.Name)] = true
+	}
+
+	var out []shippinglabel.ExistingLabel
+	for _, sub := range subs {
+		sid := fmt.Sprint(sub["id"])
+		items, err := devcenter.ListShippingLabels(cc.sess.ctx, cc.sess.client, cc.token, opt.ProductID, sid)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			l, err := shippinglabel.DecodeLabel(it)
+			if err != nil {
+				continue
+			}
+			if sid == opt.SubmissionID && names[labelKey(l.Name)] {
+				continue
+			}
+			out = append(out, shippinglabel.ExistingLabel{SubmissionID: sid, Label: *l})
+		}
+	}
+	return out, nil
+}
+
+func (cc *conflictChecker) report(c shippinglabel.Conflict) {
+	opt := cc.sess.opt
+	ex := c.Existing
+	ui.Warn(fmt.Sprintf("%q overlaps label %q (submission %s, %d PnP/CHID pair(s))",
+		c.New.Name, ex.Name, ex.SubmissionID, len(c.Overlaps)))
+	fmt.Printf("    %s\n", labelURL(opt.ProductID, ex.SubmissionID, ex.ID.String()))
+
+	o := c.Overlaps[0]
+	newVer := cc.version(opt.SubmissionID, o.New)
+	oldVer := cc.version(ex.SubmissionID, o.Old)
+	switch {
+	case newVer == "" || oldVer == "":
+		fmt.Printf("    driver: %s (new) vs %s (existing)\n", support.Or(newVer, "unknown"), support.Or(oldVer, "unknown"))
+	case drivermeta.CompareDriverVersions(oldVer, newVer) < 0:
+		ui.ErrorInside(fmt.Sprintf("existing label ships an older driver (%s < %s); the labels will compete", oldVer, newVer))
+	default:
+		fmt.Printf("    driver: %s (new) vs %s (existing)\n", newVer, oldVer)
+	}
+
+	const maxShown = 5
+	for i, o := range c.Overlaps {
+		if i == maxShown {
+			fmt.Printf("    ... and %d more\n", len(c.Overlaps)-maxShown)
+			break
+		}
+		fmt.Printf("    %s + %s\n", o.PnpID, o.CHID)
+	}
+}
+
+// version returns the driver version of a hardware ID's INF, downloading
+// the submission's driverMetadata the first time it is needed.
+func (cc *conflictChecker) version(submissionID string, h shippinglabel.HardwareID) string {
+	if _, ok := cc.versions[submissionID]; !ok {
+		cc.versions[submissionID] = map[string]string{}
+		s := cc.sess
+		if _, root, err := fetchSubmissionMetadata(s, s.opt.ProductID, submissionID); err == nil {
+			if parsed, err := drivermeta.Parse(root); err == nil {
+				cc.versions[submissionID] = drivermeta.DriverVersions(parsed.Targets)
+			}
+		}
+	}
+	return cc.versions[submissionID][drivermeta.DriverKey(h.BundleID, h.InfID)]
+}
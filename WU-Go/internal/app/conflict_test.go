@@ -0,0 +1,43 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"WU/internal/cli"
+	"WU/internal/terminal"
+)
+
+func TestConfirmAnyway(t *testing.T) {
+	color.NoColor = true
+	for _, tc := range []struct {
+		name        string
+		allow       bool
+		interactive bool
+		keys        string
+		want        int
+		shows       string
+	}{
+		{"--allow-conflicts", true, false, "", 0, "--allow-conflicts: continuing"},
+		{"non-interactive", false, false, "y\n", 1, "pass --allow-conflicts"},
+		{"answered yes", false, true, "y\n", 0, "Go on?"},
+		{"answered no", false, true, "n\n", 1, "Cancelled"},
+		{"no answer", false, true, "", 1, "Cancelled"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := terminal.NewVirtual(80, 10, tc.keys)
+			v.SetInteractive(tc.interactive)
+			defer terminal.Use(v)()
+
+			opt := &cli.CLIOptions{AllowConflicts: tc.allow}
+			if got := confirmAnyway(opt, "Stopped; pass --allow-conflicts to go on", "Go on?"); got != tc.want {
+				t.Errorf("confirmAnyway() = %d, want %d", got, tc.want)
+			}
+			if !strings.Contains(v.Screen(), tc.shows) {
+				t.Errorf("screen does not show %q:\n%s", tc.shows, v.Screen())
+			}
+		})
+	}
+}
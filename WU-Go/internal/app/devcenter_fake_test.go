@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/format"
+	"WU/internal/shippinglabel"
+	"WU/internal/terminal"
+)
+
+// fakeDevCenter serves the shipping labels of submission 1/2 from memory.
+type fakeDevCenter struct {
+	mu       sync.Mutex
+	labels   []map[string]any
+	posts    []string // names of the labels posted
+	failPost bool     // answer POSTs with 500, after storing the label when lost is set
+	lost     bool
+	nextID   int
+}
+
+const fakeLabels = "/products/1/submissions/2/shippingLabels"
+
+func (f *fakeDevCenter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != fakeLabels {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Write(format.MustJSON(map[string]any{"value": f.labels}))
+	case http.MethodPost:
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		name, _ := body["name"].(string)
+		f.posts = append(f.posts, name)
+		if f.failPost && !f.lost {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		l := f.store(body)
+		if f.failPost {
+			// created, but the response never arrives
+			http.Error(w, "gateway timeout", http.StatusGatewayTimeout)
+			return
+		}
+		w.Write(format.MustJSON(l))
+	default:
+		http.Error(w, "method", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeDevCenter) store(body map[string]any) map[string]any {
+	f.nextID++
+	body["id"] = json.Number(strconv.Itoa(1000 + f.nextID))
+	f.labels = append(f.labels, body)
+	return body
+}
+
+// addLabel puts a label on the submission, as an earlier run would have.
+func (f *fakeDevCenter) addLabel(req *shippinglabel.Request) {
+	var body map[string]any
+	json.Unmarshal(format.MustJSON(req), &body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(body)
+}
+
+func (f *fakeDevCenter) postCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.posts)
+}
+
+// newFakeSession connects a session for submission 1/2 to a fake Dev
+// Center, with a fresh history directory and a virtual terminal.
+func newFakeSession(t *testing.T) (*session, *fakeDevCenter, *terminal.Virtual) {
+	t.Helper()
+	color.NoColor = true
+	f := &fakeDevCenter{}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	v := terminal.NewVirtual(200, 60, "")
+	v.SetInteractive(false)
+	t.Cleanup(terminal.Use(v))
+
+	opt := &cli.CLIOptions{ProductID: "1", SubmissionID: "2", HistoryDir: t.TempDir()}
+	s := &session{opt: opt, ctx: context.Background(), client: devcenter.NewClient(srv.URL), token: "token"}
+	return s, f, v
+}
+
+// testLabel is a valid windowsUpdate request targeting one PnP ID.
+func testLabel(name, pnp string) *shippinglabel.Request {
+	return &shippinglabel.Request{
+		Name:        name,
+		Destination: shippinglabel.DestinationWindowsUpdate,
+		PublishingSpecifications: &shippinglabel.PublishingSpecifications{
+			ManualAcquisition: true,
+			VisibleToAccounts: []int{},
+		},
+		Targeting: shippinglabel.Targeting{
+			HardwareIDs: []shippinglabel.HardwareID{{BundleID: "b1", InfID: "net.inf", OperatingSystemCode: "WINDOWS_v100_X64_CO_FULL", PnpString: pnp}},
+			Chids:       []shippinglabel.CHID{{Chid: "6a0b0d53-0a1c-5e6a-a7a0-1b1d2c3d4e5f", DistributionState: shippinglabel.DistributionPendingAdd}},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+
+	"WU/internal/cli"
+	"WU/internal/ui"
+)
+
+// command is a top-level wu subcommand selected by the first positional
+// argument. Running wu without one starts the interactive label workflow.
+type command struct {
+	Name    string
+	Summary string
+	Usage   string
+	Run     func(opt *cli.CLIOptions) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{Name: "metadata", Summary: "Show, export and diff driverMetadata", Usage: metadataUsage, Run: runMetadata},
+		{Name: "label", Summary: "Post or list shipping labels", Usage: labelUsage, Run: runLabel},
+		{Name: "plan", Summary: "Compare a label spec file with the server", Usage: planUsage, Run: runPlan},
+		{Name: "apply", Summary: "Create or update labels to match a spec file", Usage: applyUsage, Run: runApply},
+		{Name: "history", Summary: "Search labels created by earlier runs", Usage: historyUsage, Run: runHistory},
+		{Name: "whereis", Summary: "Find the labels that target a CHID or hardware ID", Usage: whereisUsage, Run: runWhereis},
+		{Name: "chid", Summary: "Compute CHIDs and manage the CHID registry", Usage: chidUsage, Run: runCHID},
+	}
+}
+
+// Dispatch runs the subcommand named by opt.Args[0], or the interactive
+// shipping label workflow when no subcommand is given.
+func Dispatch(opt *cli.CLIOptions) int {
+	if len(opt.Args) == 0 {
+		return Run(opt)
+	}
+	name := opt.Args[0]
+	if name == "help" {
+		if len(opt.Args) > 1 {
+			if c := findCommand(opt.Args[1]); c != nil {
+				fmt.Println(c.Usage)
+				return 0
+			}
+		}
+		printUsage()
+		return 0
+	}
+	c := findCommand(name)
+	if c == nil {
+		ui.Fail("unknown command: " + name)
+		printUsage()
+		return 2
+	}
+	return c.Run(opt)
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  wu [options]                 interactive shipping label workflow")
+	fmt.Println("  wu <command> ... [options]")
+	fmt.Println("")
+	fmt.Println("The command and its operands come before any option; a bare word")
+	fmt.Println("after an option is ignored.")
+	fmt.Println("")
+	fmt.Println("Commands:")
+	for _, c := range commands {
+		fmt.Printf("  %-12s %s\n", c.Name, c.Summary)
+	}
+	fmt.Println("")
+	fmt.Println("Run 'wu help <command>' for command usage.")
+}
+
+// subcommand returns opt.Args[i] or "" when absent.
+func subcommand(opt *cli.CLIOptions, i int) string {
+	if i < len(opt.Args) {
+		return opt.Args[i]
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"WU/internal/cli"
+	"WU/internal/format"
+	"WU/internal/history"
+	"WU/internal/ui"
+)
+
+const historyUsage = `Usage:
+  wu history [--product-id <id>] [--chid <guid>] [--pnp <text>] [--format text|json]
+
+Searches the ledger of labels created or updated by wu, newest first. Each
+run is archived in its own folder (submission, request bodies, responses,
+driverMetadata hash and operator) under --history-dir, which defaults to
+"history" next to the wu executable; runs that post nothing (--dry-run,
+--metadata-file) are archived too but add nothing to the ledger.
+--no-history turns recording off for a run.
+
+  --product-id <id>   Only labels of this product
+  --chid <guid>       Only labels targeting this CHID
+  --pnp <text>        Only labels with a PnP ID containing this text`
+
+func runHistory(opt *cli.CLIOptions) int {
+	if err := checkFormat(opt.Format, "text", "json"); err != nil {
+		printErr(err)
+		return 2
+	}
+	root := historyDir(opt)
+	entries, err := history.Read(root)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	found := history.Filter(entries, history.Query{ProductID: opt.ProductID, CHID: opt.CHID, PnP: opt.PnP})
+
+	// newest first
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+
+	if opt.Format == "json" {
+		if found == nil {
+			found = []history.Entry{}
+		}
+		os.Stdout.Write(append(format.MustJSONIndent(found), '\n'))
+		return 0
+	}
+
+	if len(found) == 0 {
+		ui.Info(fmt.Sprintf("No matching labels in %s (%d recorded)", root, len(entries)))
+		return 0
+	}
+	for _, e := range found {
+		status := e.Action
+		if e.Status != "ok" {
+			status += " FAILED"
+		}
+		ui.Item(fmt.Sprintf("%s  %-14s %s", e.Time.Local().Format("2006-01-02 15:04"), status, e.LabelName))
+		fmt.Printf("    by %s on %s, product %s / submission %s", e.Operator, e.Host, e.ProductID, e.SubmissionID)
+		if e.LabelID != "" {
+			fmt.Printf(", label %s", e.LabelID)
+		}
+		fmt.Println()
+		fmt.Printf("    %s: pnp=%d chids=%d  %s\n", e.Destination, len(e.PnpIDs), len(e.CHIDs), e.RunDir)
+		if e.Notes != "" {
+			fmt.Printf("    notes: %s\n", e.Notes)
+		}
+		if e.Error != "" {
+			ui.ErrorInside(e.Error)
+		}
+	}
+	ui.Info(fmt.Sprintf("%d of %d recorded label(s)", len(found), len(entries)))
+	return 0
+}
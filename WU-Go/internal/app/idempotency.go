@@ -0,0 +1,179 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"WU/internal/devcenter"
+	"WU/internal/format"
+	"WU/internal/history"
+	"WU/internal/labelspec"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+// postLabel creates one label on opt's submission, guarded by an
+// idempotency record. The record is written before the POST and removed
+// after it succeeds; if one is found from an earlier attempt, the
+// submission is checked first so a label that was created but never
+// confirmed is reported instead of created twice; existed is then true.
+// A label of that name is matched against the recorded attempt, not the
+// current request: if it differs from the attempt it was not created by
+// it, and if the current request differs from the attempt it cannot be
+// reported as created. Either way the record is cleared and an error says
+// how to go on.
+func postLabel(sess *session, body *shippinglabel.Request, rec *recorder) (respObj map[string]any, existed bool, err error) {
+	opt := sess.opt
+	root := historyDir(opt)
+	token, err := sess.Token()
+	if err != nil {
+		return nil, false, err
+	}
+
+	prev, err := history.LoadPending(root, opt.ProductID, opt.SubmissionID, body.Name)
+	if err != nil {
+		ui.Warn("Failed to read idempotency record: " + err.Error())
+	}
+	if prev != nil {
+		existing, err := findLabelByName(sess, token, body.Name)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			// The name is taken either way, so the record is settled: keeping
+			// it would only make every later run stop here again.
+			clearPending(root, opt.ProductID, opt.SubmissionID, body.Name)
+			url := labelURL(opt.ProductID, opt.SubmissionID, existing.ID.String())
+			attempted, err := shippinglabel.ReadRequest(prev.Body)
+			if err != nil {
+				return nil, false, support.NewAPIError(fmt.Sprintf("同名标签 %q 已存在 (%s)，但上次中断时的请求记录无法读取 (%v)；已清除该记录。请核对该标签，或改用其他名称。",
+					body.Name, url, err))
+			}
+			if changes := labelspec.Changes(attempted, existing); len(changes) > 0 {
+				return nil, false, support.NewAPIError(fmt.Sprintf("同名标签 %q 已存在 (%s)，但与上次中断时提交的内容不同，并非那次创建:\n  %s\n已清除该记录。请改用其他名称，或用 wu plan/apply 更新该标签。",
+					body.Name, url, strings.Join(changes, "\n  ")))
+			}
+			if history.SHA256(body) != prev.PayloadSHA256 && len(labelspec.Changes(body, existing)) > 0 {
+				return nil, false, support.NewAPIError(fmt.Sprintf("上次中断的提交已创建标签 %q (%s)，但本次请求的内容与那次不同；已清除该记录。请改用其他名称，或用 wu plan/apply 更新该标签。",
+					body.Name, url))
+			}
+			ui.Ok("Already created by an interrupted run: " + url)
+			respObj = map[string]any{"id": existing.ID}
+			rec.record("create", body, existing.ID.String(), respObj, nil)
+			return respObj, true, nil
+		}
+		ui.Info(fmt.Sprintf("Retrying %q: the attempt from %s did not create it", body.Name, prev.Time.Local().Format("2006-01-02 15:04")))
+	}
+
+	pending := history.Pending{
+		Time:          time.Now().UTC(),
+		Operator:      history.Operator(),
+		ProductID:     opt.ProductID,
+		SubmissionID:  opt.SubmissionID,
+		Name:          body.Name,
+		PayloadSHA256: history.SHA256(body),
+		Status:        history.PendingPosting,
+		Body:          format.MustJSON(body),
+	}
+	if err := history.SavePending(root, pending); err != nil {
+		ui.Warn("Failed to write idempotency record: " + err.Error())
+	}
+
+	err = ui.Spin("Creating "+body.Name+"...", func() error {
+		var e error
+		respObj, e = devcenter.CreateShippingLabel(sess.ctx, sess.client, token, opt.ProductID, opt.SubmissionID, body)
+		return e
+	})
+	rec.record("create", body, "", respObj, err)
+	if err != nil {
+		pending.Status = history.PendingFailed
+		pending.Error = err.Error()
+		if err := history.SavePending(root, pending); err != nil {
+			ui.Warn("Failed to write idempotency record: " + err.Error())
+		}
+		return nil, false, err
+	}
+	clearPending(root, opt.ProductID, opt.SubmissionID, body.Name)
+	return respObj, false, nil
+}
+
+func clearPending(root, productID, submissionID, name string) {
+	if err := history.ClearPending(root, productID, submissionID, name); err != nil {
+		ui.Warn("Failed to remove idempotency record: " + err.Error())
+	}
+}
+
+func findLabelByName(sess *session, token, name string) (*shippinglabel.Label, error) {
+	opt := sess.opt
+	var items []map[string]any
+	err := ui.Spin("Checking for a label from the interrupted run...", func() error {
+		var e error
+		items, e = devcenter.ListShippingLabels(sess.ctx, sess.client, token, opt.ProductID, opt.SubmissionID)
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		if l, err := shippinglabel.DecodeLabel(it); err == nil && labelKey(l.Name) == labelKey(name) {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+// runResume posts the labels left behind by interrupted or failed runs,
+// limited to --product-id/--submission-id when given.
+func runResume(sess *session) int {
+	opt := sess.opt
+	productID, submissionID := opt.ProductID, opt.SubmissionID
+
+	all, err := history.ListPending(historyDir(opt))
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	var todo []history.Pending
+	for _, p := range all {
+		if (productID == "" || p.ProductID == productID) && (submissionID == "" || p.SubmissionID == submissionID) {
+			todo = append(todo, p)
+		}
+	}
+	if len(todo) == 0 {
+		ui.EndLine("Nothing to resume")
+		return 0
+	}
+
+	ui.Info(fmt.Sprintf("%d label(s) to resume", len(todo)))
+	for _, p := range todo {
+		fmt.Printf("  %s  %s/%s  %q (%s)\n", p.Time.Local().Format("2006-01-02 15:04"), p.ProductID, p.SubmissionID, p.Name, p.Status)
+	}
+
+	for _, p := range todo {
+		body, err := shippinglabel.ReadRequest(p.Body)
+		if err == nil {
+			err = shippinglabel.Validate(body, time.Now())
+		}
+		if err != nil {
+			ui.Fail("Saved request for " + p.Name + " is no longer valid")
+			reportInvalid(err)
+			return 1
+		}
+
+		opt.ProductID, opt.SubmissionID = p.ProductID, p.SubmissionID
+		rec := startRecorder(opt, "resume", nil, nil)
+		respObj, existed, err := postLabel(sess, body, rec)
+		rec.done()
+		if err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+		if !existed {
+			reportCreated(p.ProductID, p.SubmissionID, respObj)
+		}
+	}
+	ui.EndLine("Resumed")
+	return 0
+}
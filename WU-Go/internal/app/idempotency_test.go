@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"WU/internal/format"
+	"WU/internal/history"
+	"WU/internal/shippinglabel"
+)
+
+// savePending leaves an idempotency record for body, as an interrupted
+// attempt would.
+func savePending(t *testing.T, s *session, body *shippinglabel.Request, raw json.RawMessage) {
+	t.Helper()
+	if raw == nil {
+		raw = format.MustJSON(body)
+	}
+	err := history.SavePending(historyDir(s.opt), history.Pending{
+		Time:          time.Now().UTC(),
+		ProductID:     s.opt.ProductID,
+		SubmissionID:  s.opt.SubmissionID,
+		Name:          body.Name,
+		PayloadSHA256: history.SHA256(body),
+		Status:        history.PendingPosting,
+		Body:          raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pendingRecord(t *testing.T, s *session, name string) *history.Pending {
+	t.Helper()
+	p, err := history.LoadPending(historyDir(s.opt), s.opt.ProductID, s.opt.SubmissionID, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPostLabelClearsRecordAfterSuccess(t *testing.T) {
+	s, f, _ := newFakeSession(t)
+	body := testLabel("Project", `PCI\VEN_8086&DEV_0001`)
+
+	resp, existed, err := postLabel(s, body, nil)
+	if err != nil || existed || resp["id"] == nil {
+		t.Fatalf("postLabel() = %v, %v, %v", resp, existed, err)
+	}
+	if f.postCount() != 1 || pendingRecord(t, s, body.Name) != nil {
+		t.Fatalf("posts = %d, record left = %v", f.postCount(), pendingRecord(t, s, body.Name) != nil)
+	}
+}
+
+func TestPostLabelKeepsRecordOfFailedPost(t *testing.T) {
+	s, f, _ := newFakeSession(t)
+	f.failPost = true
+	body := testLabel("Project", `PCI\VEN_8086&DEV_0001`)
+
+	if _, _, err := postLabel(s, body, nil); err == nil {
+		t.Fatal("postLabel() succeeded against a failing server")
+	}
+	p := pendingRecord(t, s, body.Name)
+	if p == nil || p.Status != history.PendingFailed || p.PayloadSHA256 != history.SHA256(body) {
+		t.Fatalf("record = %+v", p)
+	}
+}
+
+func TestPostLabelResumesLostResponseWithoutPostingTwice(t *testing.T) {
+	s, f, _ := newFakeSession(t)
+	f.failPost, f.lost = true, true
+	body := testLabel("Project", `PCI\VEN_8086&DEV_0001`)
+	if _, _, err := postLabel(s, body, nil); err == nil {
+		t.Fatal("postLabel() succeeded although the response was lost")
+	}
+
+	f.failPost, f.lost = false, false
+	resp, existed, err := postLabel(s, body, nil)
+	if err != nil || !existed || resp["id"] == nil {
+		t.Fatalf("postLabel() = %v, %v, %v", resp, existed, err)
+	}
+	if f.postCount() != 1 {
+		t.Errorf("posts = %d, want 1", f.postCount())
+	}
+	if pendingRecord(t, s, body.Name) != nil {
+		t.Error("record left after the label was found")
+	}
+}
+
+func TestPostLabelRetriesWhenAttemptCreatedNothing(t *testing.T) {
+	s, f, v := newFakeSession(t)
+	body := testLabel("Project", `PCI\VEN_8086&DEV_0001`)
+	savePending(t, s, body, nil)
+
+	if _, existed, err := postLabel(s, body, nil); err != nil || existed {
+		t.Fatalf("postLabel() = %v, %v", existed, err)
+	}
+	if f.postCount() != 1 || pendingRecord(t, s, body.Name) != nil {
+		t.Fatalf("posts = %d, record left = %v", f.postCount(), pendingRecord(t, s, body.Name) != nil)
+	}
+	if !strings.Contains(v.Screen(), `Retrying "Project"`) {
+		t.Errorf("retry not reported:\n%s", v.Screen())
+	}
+}
+
+func TestPostLabelSettlesConflictingRecords(t *testing.T) {
+	attempt := testLabel("Project", `PCI\VEN_8086&DEV_0001`)
+	other := testLabel("Project", `PCI\VEN_8086&DEV_0002`)
+	for _, tc := range []struct {
+		name     string
+		existing *shippinglabel.Request // label of that name on the server
+		record   json.RawMessage        // recorded attempt; nil for attempt
+		request  *shippinglabel.Request // this run's request
+		want     string                 // in the error
+	}{
+		{"label differs from the attempt", other, nil, attempt, "并非那次创建"},
+		{"request differs from the attempt", attempt, nil, other, "本次请求的内容与那次不同"},
+		{"unreadable record", attempt, json.RawMessage(`{"bogus": true}`), attempt, "请求记录无法读取"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, f, _ := newFakeSession(t)
+			f.addLabel(tc.existing)
+			savePending(t, s, attempt, tc.record)
+
+			_, _, err := postLabel(s, tc.request, nil)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("postLabel() error = %v, want %q", err, tc.want)
+			}
+			if f.postCount() != 0 {
+				t.Errorf("posts = %d, want none", f.postCount())
+			}
+			// settled: a later run is not stopped by the same record
+			if pendingRecord(t, s, attempt.Name) != nil {
+				t.Error("record left behind")
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/drivermeta"
+	"WU/internal/format"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/ui"
+	"WU/internal/validate"
+)
+
+const labelUsage = `Usage:
+  wu label post --from <request.json> --product-id <id> --submission-id <id> [options]
+  wu label list --product-id <id> --submission-id <id>
+
+post sends a request body saved earlier (e.g. by --dry-run or --metadata-file)
+after validating it. The body can be adjusted on the way:
+
+  --from <file>          Request body to send (default: shippinglabel.request.json)
+  --name <text>          Replace the label name
+  --chids <guid...>      Replace the targeted CHIDs
+  --chids-file <file>    Replace the targeted CHIDs with those in a file (GUID
+                         list or ComputerHardwareIds.exe output); may be
+                         combined with --chids
+  --go-live-date <date>  Replace publishingSpecifications.goLiveDate
+                         (windowsUpdate labels only)
+  --out <file>           Also save the adjusted body to this file
+  --floor-os <release>   Replace the in-service floor (e.g. RS5, 21H2)
+  --ceiling-os <release> Replace the in-service ceiling
+  --audience <name...>   Replace restrictedToAudiences (IDs or names)
+  --targeting-notes <text>
+                         Notes on the targeting of an anotherPartner label,
+                         kept in the history (the API has no field for them)
+  --dry-run              Validate and show the summary, but do not POST
+  --allow-conflicts      Post even when another label of the product already
+                         targets the same PnP ID + CHID, or when that
+                         cannot be checked (no prompt)
+
+list shows the labels already created for a submission, including their
+in-service release range.`
+
+func runLabel(opt *cli.CLIOptions) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	s := newSession(ctx, opt)
+
+	switch subcommand(opt, 1) {
+	case "post":
+		return runLabelPost(s)
+	case "list":
+		return runLabelList(s)
+	default:
+		fmt.Println(labelUsage)
+		return 2
+	}
+}
+
+func runLabelPost(s *session) int {
+	opt := s.opt
+	from := support.Or(opt.From, "shippinglabel.request.json")
+
+	b, err := os.ReadFile(from)
+	if err != nil {
+		ui.Fail("Failed to read request body: " + err.Error())
+		return 1
+	}
+	body, err := shippinglabel.ReadRequest(b)
+	if err != nil {
+		ui.Fail("Request body is invalid: " + from)
+		printErr(err)
+		return 1
+	}
+
+	// Overrides
+	if !support.IsBlank(opt.Name) {
+		body.Name = opt.Name
+	}
+	if err := loadCHIDsFile(opt); err != nil {
+		printErr(err)
+		return 2
+	}
+	if len(opt.Chids) > 0 {
+		chids, err := validate.NormalizeCHIDsRequired(opt.Chids)
+		if err != nil {
+			printErr(err)
+			return 2
+		}
+		body.SetCHIDs(chids)
+	}
+	if !support.IsBlank(opt.GoLiveDate) {
+		if body.PublishingSpecifications == nil {
+			printErr(support.NewAPIError("--go-live-date 不适用于 anotherPartner 标签"))
+			return 2
+		}
+		goLive, err := shippinglabel.NormalizeGoLiveDate(opt.GoLiveDate)
+		if err != nil {
+			printErr(err)
+			return 2
+		}
+		body.PublishingSpecifications.GoLiveDate = goLive
+	}
+
+	if !support.IsBlank(opt.FloorOS) || !support.IsBlank(opt.CeilingOS) {
+		body.Targeting.InServicePublishInfo = shippinglabel.InServiceRange(opt.FloorOS, opt.CeilingOS)
+	}
+	if len(opt.Audiences) > 0 {
+		list, err := fetchAudiences(s)
+		if err == nil {
+			opt.Audiences, err = resolveAudiences(list, opt.Audiences)
+		}
+		if err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+		body.SetAudiences(opt.Audiences)
+	}
+
+	if err := checkTargetingNotes(opt, body.Destination); err != nil {
+		printErr(err)
+		return 2
+	}
+	if err := shippinglabel.Validate(body, time.Now()); err != nil {
+		reportInvalid(err)
+		return 1
+	}
+	ui.Ok("Request body valid: " + from)
+	printRequestSummary(body)
+	printTargetingNotes(opt)
+
+	if !support.IsBlank(opt.OutPath) {
+		if err := os.WriteFile(opt.OutPath, format.MustJSONIndent(body), 0644); err != nil {
+			ui.Fail("Failed to write request body: " + err.Error())
+			return 1
+		}
+		ui.Ok("Request saved: " + opt.OutPath)
+	}
+
+	if opt.DryRun {
+		rec := startRecorder(opt, "label post (dry-run)", nil, nil)
+		rec.recordUnposted(body)
+		rec.done()
+		ui.EndLine("--dry-run (no POST)")
+		return 0
+	}
+
+	promptSubmissionIDs(opt)
+	if support.IsBlank(opt.ProductID) || support.IsBlank(opt.SubmissionID) {
+		ui.Fail("product_id / submission_id cannot be empty")
+		return 2
+	}
+
+	if _, err := s.Token(); err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	if code := checkConflicts(s, []*shippinglabel.Request{body}, nil); code != 0 {
+		return code
+	}
+
+	rec := startRecorder(opt, "label post", nil, nil)
+	defer rec.done()
+
+	respObj, existed, err := postLabel(s, body, rec)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	if !existed {
+		reportCreated(opt.ProductID, opt.SubmissionID, respObj)
+	}
+	return 0
+}
+
+// printRequestSummary shows the fields a reviewer checks before posting.
+func printRequestSummary(body *shippinglabel.Request) {
+	goLive := ""
+	if p := body.PublishingSpecifications; p != nil {
+		goLive = p.GoLiveDate
+	}
+	fmt.Printf("  name:        %s\n", body.Name)
+	fmt.Printf("  destination: %s\n", body.Destination)
+	if r := body.RecipientSpecifications; r != nil {
+		fmt.Printf("  partner:     %s (enforceChidTargeting=%v)\n", r.ReceiverPublisherID, r.EnforceChidTargeting)
+	}
+	fmt.Printf("  hardwareIds: %d\n", len(body.Targeting.HardwareIDs))
+	fmt.Printf("  chids:       %d\n", len(body.Targeting.Chids))
+	if body.PublishingSpecifications != nil {
+		fmt.Printf("  goLiveDate:  %s\n", support.Or(goLive, "(immediate)"))
+	}
+	if body.Destination != shippinglabel.DestinationAnotherPartner {
+		fmt.Printf("  inService:   %s\n", describeInService(body.Targeting.InServicePublishInfo))
+		fmt.Printf("  audiences:   %s\n", describeAudiences(body.Targeting.RestrictedToAudiences))
+	}
+}
+
+// checkTargetingNotes rejects --targeting-notes for labels that are not
+// shared with a partner.
+func checkTargetingNotes(opt *cli.CLIOptions, destination string) error {
+	if opt.TargetingNotes != "" && destination != shippinglabel.DestinationAnotherPartner {
+		return support.NewAPIError("--targeting-notes 仅适用于 anotherPartner 标签")
+	}
+	return nil
+}
+
+// printTargetingNotes shows the notes under the summary; they are not part
+// of the request body.
+func printTargetingNotes(opt *cli.CLIOptions) {
+	if opt.TargetingNotes != "" {
+		fmt.Printf("  notes:       %s (history only, not sent)\n", opt.TargetingNotes)
+	}
+}
+
+func describeAudiences(ids []string) string {
+	if len(ids) == 0 {
+		return "(everyone)"
+	}
+	return strings.Join(ids, ", ")
+}
+
+// describeInService renders a floor/ceiling range, e.g.
+// "RS5 (Windows 10 1809) .. NI (Windows 11 22H2)".
+func describeInService(isp *shippinglabel.InServicePublishInfo) string {
+	if isp == nil || (support.IsBlank(isp.Flooring) && support.IsBlank(isp.Ceiling)) {
+		return "(all releases)"
+	}
+	end := func(v, open string) string {
+		if support.IsBlank(v) {
+			return open
+		}
+		return drivermeta.DescribeRelease(v)
+	}
+	return end(isp.Flooring, "(any)") + " .. " + end(isp.Ceiling, "(latest)")
+}
+
+func runLabelList(s *session) int {
+	opt := s.opt
+	promptSubmissionIDs(opt)
+	if support.IsBlank(opt.ProductID) || support.IsBlank(opt.SubmissionID) {
+		ui.Fail("product_id / submission_id cannot be empty")
+		return 2
+	}
+
+	token, err := s.Token()
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+
+	var items []map[string]any
+	err = ui.Spin("Fetching shipping labels...", func() error {
+		var e error
+		items, e = devcenter.ListShippingLabels(s.ctx, s.client, token, opt.ProductID, opt.SubmissionID)
+		return e
+	})
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	if len(items) == 0 {
+		ui.EndLine("No shipping labels on this submission")
+		return 0
+	}
+
+	ui.Ok(fmt.Sprintf("%d shipping label(s)", len(items)))
+	for _, it := range items {
+		l, err := shippinglabel.DecodeLabel(it)
+		if err != nil {
+			printErr(err)
+			continue
+		}
+		ui.Item(fmt.Sprintf("%s  %s", l.ID, l.Name))
+		fmt.Printf("    destination: %s\n", l.Destination)
+		if w := l.WorkflowStatus; w != nil {
+			fmt.Printf("    workflow:    step=%s state=%s\n", w.CurrentStep, w.State)
+		}
+		fmt.Printf("    hardwareIds: %d, chids: %d\n", len(l.Targeting.HardwareIDs), len(l.Targeting.Chids))
+		if l.Destination != shippinglabel.DestinationAnotherPartner {
+			fmt.Printf("    inService:   %s\n", describeInService(l.Targeting.InServicePublishInfo))
+			fmt.Printf("    audiences:   %s\n", describeAudiences(l.Targeting.RestrictedToAudiences))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"WU/internal/cli"
+	"WU/internal/format"
+	"WU/internal/history"
+	"WU/internal/shippinglabel"
+)
+
+func TestCheckTargetingNotes(t *testing.T) {
+	for _, tc := range []struct {
+		notes, destination string
+		ok                 bool
+	}{
+		{"", shippinglabel.DestinationWindowsUpdate, true},
+		{"ODM pilot units only", shippinglabel.DestinationAnotherPartner, true},
+		{"ODM pilot units only", shippinglabel.DestinationWindowsUpdate, false},
+	} {
+		err := checkTargetingNotes(&cli.CLIOptions{TargetingNotes: tc.notes}, tc.destination)
+		if (err == nil) != tc.ok {
+			t.Errorf("checkTargetingNotes(%q, %s) = %v", tc.notes, tc.destination, err)
+		}
+	}
+}
+
+func TestLabelPostRejectsGoLiveDateForPartnerLabels(t *testing.T) {
+	s, f, _ := newFakeSession(t)
+	body := testLabel("Shared", `PCI\VEN_8086&DEV_0001`)
+	body.Destination = shippinglabel.DestinationAnotherPartner
+	body.PublishingSpecifications = nil
+	body.RecipientSpecifications = &shippinglabel.RecipientSpecifications{ReceiverPublisherID: "12345"}
+	from := filepath.Join(t.TempDir(), "request.json")
+	if err := os.WriteFile(from, format.MustJSON(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.opt.From, s.opt.GoLiveDate = from, "2030-01-02"
+
+	if code := runLabelPost(s); code != 2 {
+		t.Fatalf("runLabelPost() = %d, want 2", code)
+	}
+	if f.postCount() != 0 {
+		t.Errorf("posted %d label(s)", f.postCount())
+	}
+}
+
+func TestLabelPostArchivesDryRunWithoutLedgerEntry(t *testing.T) {
+	s, f, v := newFakeSession(t)
+	from := filepath.Join(t.TempDir(), "request.json")
+	if err := os.WriteFile(from, format.MustJSON(testLabel("Project", `PCI\VEN_8086&DEV_0001`)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.opt.From, s.opt.DryRun = from, true
+
+	if code := runLabelPost(s); code != 0 {
+		t.Fatalf("runLabelPost() = %d\n%s", code, v.Screen())
+	}
+	if f.postCount() != 0 {
+		t.Errorf("posted %d label(s)", f.postCount())
+	}
+	requests, _ := filepath.Glob(filepath.Join(s.opt.HistoryDir, "*-label-post-(dry-run)", "01-request.json"))
+	if len(requests) != 1 {
+		t.Errorf("archived requests = %q", requests)
+	}
+	if entries, err := history.Read(s.opt.HistoryDir); err != nil || len(entries) != 0 {
+		t.Errorf("ledger = %v, %v; want empty", entries, err)
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"WU/internal/cli"
+	"WU/internal/drivermeta"
+	"WU/internal/format"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+const metadataUsage = `Usage:
+  wu metadata show   [<source>]
+  wu metadata export [<source>] [--format csv|json|md] [--out <file>] [--os-filter <expr>]
+  wu metadata diff   <old> <new> [--format text|json] [--verbose]
+
+<source> is a driverMetadata JSON file, "productId/submissionId",
+a submission shortcut, or a submission ID combined with --product-id.
+Without <source>, --metadata-file or --product-id/--submission-id is used.
+
+  --format      export: csv (default) | json | md; diff: text (default) | json
+  --out         export: write to a file instead of stdout
+  --os-filter   export: only targets matching e.g. "arch=x64 release>=21H2"
+  --verbose     diff: also list unchanged targets in text output`
+
+func runMetadata(opt *cli.CLIOptions) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	s := newSession(ctx, opt)
+
+	switch subcommand(opt, 1) {
+	case "show":
+		return runMetadataShow(s)
+	case "export":
+		return runMetadataExport(s)
+	case "diff":
+		return runMetadataDiff(s)
+	default:
+		fmt.Println(metadataUsage)
+		return 2
+	}
+}
+
+// loadParsedSource loads and parses the metadata named by the optional
+// positional argument at opt.Args[2], falling back to --product-id and
+// --submission-id.
+func loadParsedSource(s *session) (*drivermeta.ParseResult, error) {
+	opt := s.opt
+	var metaRoot map[string]any
+	var err error
+	if ref := subcommand(opt, 2); ref != "" {
+		metaRoot, err = loadMetadataRef(s, ref)
+	} else if !support.IsBlank(opt.MetadataFile) {
+		metaRoot, err = readMetadataFile(opt.MetadataFile)
+	} else if !support.IsBlank(opt.ProductID) && !support.IsBlank(opt.SubmissionID) {
+		_, metaRoot, err = fetchSubmissionMetadata(s, opt.ProductID, opt.SubmissionID)
+	} else {
+		return nil, support.NewAPIError("缺少 metadata 来源（<source> 或 --product-id/--submission-id）")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return drivermeta.Parse(metaRoot)
+}
+
+func runMetadataShow(s *session) int {
+	parsed, err := loadParsedSource(s)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	drivermeta.WriteTree(os.Stdout, parsed)
+	drivermeta.WriteStats(os.Stdout, parsed)
+	return 0
+}
+
+func runMetadataExport(s *session) int {
+	opt := s.opt
+	if err := checkFormat(opt.Format, drivermeta.ExportFormats...); err != nil {
+		printErr(err)
+		return 2
+	}
+	parsed, err := loadParsedSource(s)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+
+	targets := parsed.Targets
+	if !support.IsBlank(opt.OSFilter) {
+		f, err := drivermeta.ParseOSFilter(opt.OSFilter)
+		if err != nil {
+			printErr(err)
+			return 2
+		}
+		targets = drivermeta.FilterTargetsByOS(targets, f)
+	}
+
+	if support.IsBlank(opt.OutPath) {
+		if err := drivermeta.WriteExport(os.Stdout, targets, support.Or(opt.Format, "csv")); err != nil {
+			printErr(err)
+			return 1
+		}
+		return 0
+	}
+
+	f, err := os.Create(opt.OutPath)
+	if err != nil {
+		ui.Fail("Failed to create " + opt.OutPath + ": " + err.Error())
+		return 1
+	}
+	err = drivermeta.WriteExport(f, targets, support.Or(opt.Format, "csv"))
+	// a failed Close can mean the data never reached the disk
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		printErr(err)
+		return 1
+	}
+	ui.Ok(fmt.Sprintf("Exported %d targets: %s", len(targets), opt.OutPath))
+	return 0
+}
+
+func runMetadataDiff(s *session) int {
+	opt := s.opt
+	if len(opt.Args) != 4 {
+		fmt.Println(metadataUsage)
+		return 2
+	}
+	if err := checkFormat(opt.Format, "text", "json"); err != nil {
+		printErr(err)
+		return 2
+	}
+
+	var results [2]*drivermeta.ParseResult
+	for i, ref := range opt.Args[2:4] {
+		metaRoot, err := loadMetadataRef(s, ref)
+		if err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+		results[i], err = drivermeta.Parse(metaRoot)
+		if err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+	}
+
+	d := drivermeta.Diff(results[0], results[1])
+	if opt.Format == "json" {
+		os.Stdout.Write(format.MustJSONIndent(d))
+		fmt.Println()
+		return 0
+	}
+	drivermeta.WriteDiffText(os.Stdout, d, opt.Verbose)
+	return 0
+}
+
+// checkFormat validates --format against the values a command supports;
+// an empty value selects the first one.
+func checkFormat(v string, allowed ...string) error {
+	if support.IsBlank(v) {
+		return nil
+	}
+	for _, a := range allowed {
+		if v == a {
+			return nil
+		}
+	}
+	return support.NewAPIError(fmt.Sprintf("--format 不支持 %q（可用: %v）", v, allowed))
+}
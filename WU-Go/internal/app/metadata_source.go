@@ -0,0 +1,77 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+// parseSubmissionRef accepts "productId/submissionId", a submission shortcut,
+// or a bare submission ID combined with defaultProduct.
+func parseSubmissionRef(ref, defaultProduct string) (productID, submissionID string, ok bool) {
+	ref = strings.TrimSpace(ref)
+	if p, s, found := strings.Cut(ref, "/"); found && !support.IsBlank(p) && !support.IsBlank(s) {
+		return strings.TrimSpace(p), strings.TrimSpace(s), true
+	}
+	if p, s, ok := cli.TryParseSubmissionShortcut(ref); ok {
+		return p, s, true
+	}
+	if !support.IsBlank(ref) && !support.IsBlank(defaultProduct) {
+		return defaultProduct, ref, true
+	}
+	return "", "", false
+}
+
+// readMetadataFile loads a driverMetadata document saved on disk.
+func readMetadataFile(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, support.NewAPIError("读取 driverMetadata 文件失败: " + err.Error())
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, support.NewAPIError("driverMetadata 文件不是合法 JSON: " + path + "\n" + err.Error())
+	}
+	return obj, nil
+}
+
+// fetchSubmissionMetadata downloads a submission and its driverMetadata.
+func fetchSubmissionMetadata(s *session, productID, submissionID string) (submission, metaRoot map[string]any, err error) {
+	token, err := s.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = ui.Spin("Fetching driverMetadata "+productID+"/"+submissionID+"...", func() error {
+		var err error
+		submission, err = devcenter.GetSubmission(s.ctx, s.client, token, productID, submissionID)
+		if err != nil {
+			return err
+		}
+		u, err := devcenter.FindDriverMetadataURL(submission)
+		if err != nil {
+			return err
+		}
+		metaRoot, err = devcenter.DownloadDriverMetadata(s.ctx, s.client, token, u)
+		return err
+	})
+	return submission, metaRoot, err
+}
+
+// loadMetadataRef resolves ref to a driverMetadata document: an existing
+// file is read locally, anything else is treated as a submission reference.
+func loadMetadataRef(s *session, ref string) (map[string]any, error) {
+	if st, err := os.Stat(ref); err == nil && !st.IsDir() {
+		return readMetadataFile(ref)
+	}
+	p, sub, ok := parseSubmissionRef(ref, s.opt.ProductID)
+	if !ok {
+		return nil, support.NewAPIError("无法识别的 metadata 来源（需为文件路径、productId/submissionId 或 submission 快捷串）: " + ref)
+	}
+	_, metaRoot, err := fetchSubmissionMetadata(s, p, sub)
+	return metaRoot, err
+}
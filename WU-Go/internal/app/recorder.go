@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"WU/internal/cli"
+	"WU/internal/history"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+// recorder archives a run and appends each posted label to the ledger. Runs
+// that post nothing (--dry-run, --metadata-file) are archived without ledger
+// entries. Archive problems are only warned about; they never stop a run. A
+// nil recorder (--no-history) records nothing.
+type recorder struct {
+	root string
+	run  *history.Run
+	base history.Entry
+	seq  int
+}
+
+// historyDir is --history-dir, or "history" next to the executable like
+// credential.json.
+func historyDir(opt *cli.CLIOptions) string {
+	if !support.IsBlank(opt.HistoryDir) {
+		return opt.HistoryDir
+	}
+	return filepath.Join(filepath.Dir(credentialPath()), "history")
+}
+
+// startRecorder opens the archive folder of this run and stores the
+// submission (when known) and a manifest.
+func startRecorder(opt *cli.CLIOptions, command string, submission, metaRoot map[string]any) *recorder {
+	if opt.NoHistory {
+		return nil
+	}
+	root := historyDir(opt)
+	now := time.Now()
+	run, err := history.StartRun(root, command, now)
+	if err != nil {
+		ui.Warn("History disabled: " + err.Error())
+		return nil
+	}
+
+	host, _ := os.Hostname()
+	r := &recorder{root: root, run: run, base: history.Entry{
+		Operator:     history.Operator(),
+		Host:         host,
+		Command:      command,
+		ProductID:    opt.ProductID,
+		SubmissionID: opt.SubmissionID,
+		Notes:        opt.TargetingNotes,
+		RunDir:       run.Dir,
+	}}
+	if metaRoot != nil {
+		r.base.MetadataSHA256 = history.SHA256(metaRoot)
+	}
+
+	manifest := r.base
+	manifest.Time = now
+	r.write("run.json", manifest)
+	if submission != nil {
+		r.write("submission.json", submission)
+	}
+	return r
+}
+
+// record stores the request and response of one label and adds it to the
+// ledger. labelID is used when the response does not carry one (updates).
+func (r *recorder) record(action string, body *shippinglabel.Request, labelID string, respObj map[string]any, err error) {
+	if r == nil {
+		return
+	}
+	r.seq++
+	prefix := fmt.Sprintf("%02d-", r.seq)
+	r.write(prefix+"request.json", body)
+	if respObj != nil {
+		r.write(prefix+"response.json", respObj)
+	}
+
+	e := r.base
+	e.Time = time.Now()
+	e.Action = action
+	e.Status = "ok"
+	if err != nil {
+		e.Status = "failed"
+		e.Error = err.Error()
+	}
+	if id, ok := support.TryGetInt64(respObj, "id"); ok {
+		labelID = fmt.Sprint(id)
+	}
+	e.LabelID = labelID
+	e.LabelName = body.Name
+	e.Destination = body.Destination
+	seen := map[string]bool{}
+	for _, h := range body.Targeting.HardwareIDs {
+		if !seen[h.PnpString] {
+			seen[h.PnpString] = true
+			e.PnpIDs = append(e.PnpIDs, h.PnpString)
+		}
+	}
+	for _, c := range body.Targeting.Chids {
+		e.CHIDs = append(e.CHIDs, c.Chid)
+	}
+	if err := history.Append(r.root, e); err != nil {
+		ui.Warn("Failed to write history ledger: " + err.Error())
+	}
+}
+
+// recordUnposted stores the request of a label the run prepared but did not
+// post. It has no response and no ledger entry.
+func (r *recorder) recordUnposted(body *shippinglabel.Request) {
+	if r == nil {
+		return
+	}
+	r.seq++
+	r.write(fmt.Sprintf("%02d-request.json", r.seq), body)
+}
+
+// done tells the user where the run was archived.
+func (r *recorder) done() {
+	if r == nil || r.seq == 0 {
+		return
+	}
+	ui.Item("Archived", r.run.Dir)
+}
+
+func (r *recorder) write(name string, v any) {
+	if err := r.run.WriteJSON(name, v); err != nil {
+		ui.Warn("Failed to archive " + name + ": " + err.Error())
+	}
+}
@@ -2,19 +2,22 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	"WU/internal/auth"
+	"WU/internal/chid"
 	"WU/internal/cli"
 	"WU/internal/devcenter"
 	"WU/internal/drivermeta"
 	"WU/internal/format"
 	"WU/internal/shippinglabel"
 	"WU/internal/support"
+	"WU/internal/terminal"
 	"WU/internal/tui"
 	"WU/internal/ui"
 	"WU/internal/validate"
@@ -22,94 +25,243 @@ import (
 
 const (
 	baseAPI                    = "https://manage.devcenter.microsoft.com/v2.0/my/hardware"
-	partnerShippingURLTemplate = "https://partner.microsoft.com/en-us/dashboard/hardware/driver/%s/submission/%s/ShippingLabel/%d"
+	partnerShippingURLTemplate = "https://partner.microsoft.com/en-us/dashboard/hardware/driver/%s/submission/%s/ShippingLabel/%s"
 )
 
 func Run(opt *cli.CLIOptions) int {
 	ui.Banner("WU", "1.0.0")
 	ui.EndLine("Start")
 
-	// ---- Step 1: Initialize & Auth ----
-	ui.Section(ui.StepCtx{Title: "Initialize", Current: 1, Total: 4})
-	ui.Item("Loading credentials", "credential.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	sess := newSession(ctx, opt)
 
-	credPath := credentialPath()
-	cred := auth.LoadCredential(credPath)
+	// --resume: post what interrupted runs left behind, skip selection.
+	if opt.Resume {
+		return runResume(sess)
+	}
 
-	// CLI/env override > credential.json
-	opt.TenantID = support.FirstNonEmpty(cred.TenantID, opt.TenantID, os.Getenv("HW_TENANT_ID"))
-	opt.ClientID = support.FirstNonEmpty(cred.ClientID, opt.ClientID, os.Getenv("HW_CLIENT_ID"))
-	opt.ClientSecret = support.FirstNonEmpty(cred.ClientSecret, opt.ClientSecret, os.Getenv("HW_CLIENT_SECRET"))
+	// --metadata-file: no auth, no fetch, no POST.
+	offline := !support.IsBlank(opt.MetadataFile)
 
-	// Prompt if missing
-	if support.IsBlank(opt.TenantID) {
-		opt.TenantID = ui.Prompt("tenant_id", "")
-	}
-	if support.IsBlank(opt.ClientID) {
-		opt.ClientID = ui.Prompt("client_id", "")
+	var submission, metaRoot map[string]any
+	var code int
+	if offline {
+		metaRoot, code = loadOfflineMetadata(opt)
+	} else {
+		submission, metaRoot, code = fetchOnlineMetadata(sess)
 	}
-	if support.IsBlank(opt.ClientSecret) {
-		opt.ClientSecret = ui.PromptSecret("client_secret")
+	if code != 0 {
+		return code
 	}
 
-	// Save back
-	cred.TenantID = opt.TenantID
-	cred.ClientID = opt.ClientID
-	cred.ClientSecret = opt.ClientSecret
-	auth.SaveCredential(credPath, cred)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
-	defer cancel()
-	httpClient := devcenter.NewClient(baseAPI)
-
-	// Acquire Token (MOVED HERE per requirements)
-	var token string
-	err := ui.Spin("Acquiring token...", func() error {
+	var parsed *drivermeta.ParseResult
+	err := ui.Spin("Parsing candidates...", func() error {
 		var err error
-		token, err = auth.AcquireToken(ctx, httpClient.HTTP, opt.TenantID, opt.ClientID, opt.ClientSecret)
+		parsed, err = drivermeta.Parse(metaRoot)
 		return err
 	})
 	if err != nil {
-		ui.Fail("Token acquisition failed")
 		printErr(err)
 		return exitCode(err)
 	}
-	ui.Ok("Token acquired")
 
-	// ---- Step 2: Submission Selection ----
-	ui.Section(ui.StepCtx{Title: "Submission Selection", Current: 2, Total: 4})
+	ui.Ok(fmt.Sprintf("Metadata OK: candidates=%d", len(parsed.Targets)))
 
-	if support.IsBlank(opt.ProductID) {
-		raw := ui.Prompt("productId (or submission shortcut)", "")
-		if p, s, ok := cli.TryParseSubmissionShortcut(raw); ok {
-			opt.ProductID = p
-			if support.IsBlank(opt.SubmissionID) {
-				opt.SubmissionID = s
+	if len(parsed.Targets) == 0 {
+		ui.Fail("No candidates found in metadata")
+		return 1
+	}
+
+	// Selection
+	ui.Section(ui.StepCtx{Title: "Selection", Current: 3, Total: 4})
+
+	candidates, err := applyOSRules(parsed.Targets, opt)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	if len(candidates) == 0 {
+		ui.Fail("No candidates match --os-filter")
+		return 1
+	}
+
+	var selected []drivermeta.HardwareTarget
+	if opt.SelectAll {
+		selected = append(selected, candidates...)
+		ui.Ok(fmt.Sprintf("--select-all: selected %d hardwareIds", len(selected)))
+	} else {
+		fmt.Println("")
+		selected, err = selectTargets(parsed, candidates, opt)
+		if err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+		if len(selected) == 0 {
+			ui.Fail("No hardwareIds selected")
+			return 1
+		}
+		ui.Ok(fmt.Sprintf("Selected %d hardwareIds", len(selected)))
+	}
+	ui.EndLine("Selected")
+
+	// ---- Step 4: Create Label ----
+	ui.Section(ui.StepCtx{Title: "Create Shipping Label", Current: 4, Total: 4})
+
+	name := opt.Name
+	if support.IsBlank(name) {
+		name = ui.Prompt("Shipping label name", "{OEM Name}: {Project Name}")
+		if name == "{OEM Name}: {Project Name}" {
+			// handled by prompt returning default if user hits enter
+		}
+	}
+
+	if opt.Destination == shippinglabel.DestinationAnotherPartner && support.IsBlank(opt.ReceiverPublisherID) {
+		opt.ReceiverPublisherID = ui.Prompt("Partner publisher ID (receiverPublisherId)", "")
+		opt.EnforceChidTargeting = ui.PromptYesNo("Restrict the partner to the CHIDs in this label?", opt.EnforceChidTargeting)
+		if support.IsBlank(opt.TargetingNotes) {
+			opt.TargetingNotes = strings.TrimSpace(ui.Prompt("Targeting notes for the partner (kept in the history, not sent; blank for none)", ""))
+		}
+	}
+	if err := checkTargetingNotes(opt, opt.Destination); err != nil {
+		printErr(err)
+		return 2
+	}
+
+	if opt.Destination != shippinglabel.DestinationAnotherPartner && support.IsBlank(opt.FloorOS) && support.IsBlank(opt.CeilingOS) {
+		promptInServiceRange(opt)
+	}
+	// Offline runs cannot look audiences up; --audience values go in as IDs.
+	if opt.Destination != shippinglabel.DestinationAnotherPartner && !offline {
+		if err := chooseAudiences(sess); err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+	}
+
+	// Sharing with a partner does not need CHIDs unless CHID targeting is enforced.
+	chidsRequired := opt.Destination != shippinglabel.DestinationAnotherPartner || opt.EnforceChidTargeting
+	chids, err := collectCHIDs(opt, chidsRequired)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+
+	bodies, err := buildLabelBodies(opt, name, selected, chids)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	for i, b := range bodies {
+		if err := shippinglabel.Validate(b, time.Now()); err != nil {
+			if len(bodies) > 1 {
+				ui.Fail(fmt.Sprintf("Label %d/%d: %s", i+1, len(bodies), b.Name))
 			}
-		} else {
-			opt.ProductID = raw
+			reportInvalid(err)
+			return 1
 		}
 	}
-	if support.IsBlank(opt.SubmissionID) {
-		opt.SubmissionID = ui.Prompt("submissionId", "")
+	if len(bodies) == 1 {
+		printRequestSummary(bodies[0])
+	} else {
+		printSplitSummary(bodies)
+	}
+	printTargetingNotes(opt)
+
+	outPath := opt.OutPath
+	if support.IsBlank(outPath) {
+		outPath = "shippinglabel.request.json"
+	}
+	for i, b := range bodies {
+		p := splitOutPath(outPath, i, len(bodies))
+		if err := os.WriteFile(p, format.MustJSONIndent(b), 0644); err != nil {
+			ui.Fail("Failed to write request body: " + err.Error())
+			return 1
+		}
+		ui.Ok("Request saved: " + p)
 	}
 
+	if offline || opt.DryRun {
+		command, end := "run (dry-run)", "--dry-run (no POST)"
+		if offline {
+			command, end = "run (offline)", "--metadata-file (offline, no POST)"
+		}
+		rec := startRecorder(opt, command, submission, metaRoot)
+		for _, b := range bodies {
+			rec.recordUnposted(b)
+		}
+		rec.done()
+		ui.EndLine(end)
+		return 0
+	}
+
+	if code := checkConflicts(sess, bodies, drivermeta.DriverVersions(parsed.Targets)); code != 0 {
+		return code
+	}
+
+	rec := startRecorder(opt, "run", submission, metaRoot)
+	defer rec.done()
+
+	if len(bodies) > 1 {
+		if code := createSplitLabels(sess, bodies, rec); code != 0 {
+			return code
+		}
+		ui.EndLine("Complete")
+		ui.Prompt("Press Enter to exit", "")
+		return 0
+	}
+
+	respObj, existed, err := postLabel(sess, bodies[0], rec)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	if !existed {
+		reportCreated(opt.ProductID, opt.SubmissionID, respObj)
+	}
+
+	ui.EndLine("Complete")
+	ui.Prompt("Press Enter to exit", "")
+	return 0
+}
+
+// fetchOnlineMetadata runs Steps 1-3 of the online flow: authenticate, pick
+// the submission and download its driverMetadata. A non-zero code means the
+// error was already reported.
+func fetchOnlineMetadata(sess *session) (submission, metaRoot map[string]any, code int) {
+	opt := sess.opt
+
+	// ---- Step 1: Initialize & Auth ----
+	ui.Section(ui.StepCtx{Title: "Initialize", Current: 1, Total: 4})
+	ui.Item("Loading credentials", "credential.json")
+
+	// Acquire Token (MOVED HERE per requirements)
+	token, err := sess.Token()
+	if err != nil {
+		printErr(err)
+		return nil, nil, exitCode(err)
+	}
+
+	// ---- Step 2: Submission Selection ----
+	ui.Section(ui.StepCtx{Title: "Submission Selection", Current: 2, Total: 4})
+
+	promptSubmissionIDs(opt)
+
 	if support.IsBlank(opt.TenantID) || support.IsBlank(opt.ClientID) || support.IsBlank(opt.ClientSecret) ||
 		support.IsBlank(opt.ProductID) || support.IsBlank(opt.SubmissionID) {
 		ui.Fail("tenant_id / client_id / client_secret / product_id / submission_id cannot be empty")
-		return 2
+		return nil, nil, 2
 	}
 
-	var submission map[string]any
 	err = ui.Spin("Fetching submission...", func() error {
 		var err error
-		submission, err = devcenter.GetSubmission(ctx, httpClient, token, opt.ProductID, opt.SubmissionID)
+		submission, err = devcenter.GetSubmission(sess.ctx, sess.client, token, opt.ProductID, opt.SubmissionID)
 		return err
 	})
 	if err != nil {
 		ui.Fail("Fetch submission failed")
 		printErr(err)
-		return exitCode(err)
+		return nil, nil, exitCode(err)
 	}
 	ui.Ok("Submission fetched")
 
@@ -120,7 +272,6 @@ func Run(opt *cli.CLIOptions) int {
 	// ---- Step 3: Metadata & Target Selection ----
 	ui.Section(ui.StepCtx{Title: "Metadata Analysis", Current: 3, Total: 4})
 
-	var metaRoot map[string]any
 	var driverMetadataURL string
 
 	err = ui.Spin("Resolving metadata URL...", func() error {
@@ -130,149 +281,258 @@ func Run(opt *cli.CLIOptions) int {
 	})
 	if err != nil {
 		printErr(err)
-		return exitCode(err)
+		return nil, nil, exitCode(err)
 	}
 
 	err = ui.Spin("Downloading driverMetadata...", func() error {
 		var err error
-		metaRoot, err = devcenter.DownloadDriverMetadata(ctx, httpClient, token, driverMetadataURL)
+		metaRoot, err = devcenter.DownloadDriverMetadata(sess.ctx, sess.client, token, driverMetadataURL)
 		return err
 	})
 	if err != nil {
 		printErr(err)
-		return exitCode(err)
+		return nil, nil, exitCode(err)
 	}
+	return submission, metaRoot, 0
+}
 
-	var parsed *drivermeta.ParseResult
-	err = ui.Spin("Parsing candidates...", func() error {
+// loadOfflineMetadata is the --metadata-file counterpart of
+// fetchOnlineMetadata: IDs come only from flags and nothing is fetched.
+func loadOfflineMetadata(opt *cli.CLIOptions) (map[string]any, int) {
+	ui.Section(ui.StepCtx{Title: "Initialize", Current: 1, Total: 4})
+	ui.Info("--metadata-file: offline mode, skipping authentication")
+
+	ui.Section(ui.StepCtx{Title: "Submission Selection", Current: 2, Total: 4})
+	ui.ItemValue("productId", support.Or(opt.ProductID, "(not set)"))
+	ui.ItemValue("submissionId", support.Or(opt.SubmissionID, "(not set)"))
+
+	ui.Section(ui.StepCtx{Title: "Metadata Analysis", Current: 3, Total: 4})
+	var metaRoot map[string]any
+	err := ui.Spin("Reading driverMetadata...", func() error {
 		var err error
-		parsed, err = drivermeta.Parse(metaRoot)
+		metaRoot, err = readMetadataFile(opt.MetadataFile)
 		return err
 	})
 	if err != nil {
 		printErr(err)
-		return exitCode(err)
+		return nil, exitCode(err)
 	}
+	ui.Ok("Loaded: " + opt.MetadataFile)
+	return metaRoot, 0
+}
 
-	ui.Ok(fmt.Sprintf("Metadata OK: candidates=%d", len(parsed.Targets)))
-
-	if len(parsed.Targets) == 0 {
-		ui.Fail("No candidates found in metadata")
-		return 1
+// promptSubmissionIDs asks for whichever of productId/submissionId is
+// missing; a submission shortcut fills both.
+func promptSubmissionIDs(opt *cli.CLIOptions) {
+	if support.IsBlank(opt.ProductID) {
+		raw := ui.Prompt("productId (or submission shortcut)", "")
+		if p, s, ok := cli.TryParseSubmissionShortcut(raw); ok {
+			opt.ProductID = p
+			if support.IsBlank(opt.SubmissionID) {
+				opt.SubmissionID = s
+			}
+		} else {
+			opt.ProductID = raw
+		}
 	}
+	if support.IsBlank(opt.SubmissionID) {
+		opt.SubmissionID = ui.Prompt("submissionId", "")
+	}
+}
 
-	// Selection
-	ui.Section(ui.StepCtx{Title: "Selection", Current: 3, Total: 4})
-
-	var selected []drivermeta.HardwareTarget
-	if opt.SelectAll {
-		selected = append(selected, parsed.Targets...)
-		ui.Ok(fmt.Sprintf("--select-all: selected %d hardwareIds", len(selected)))
+// reportCreated prints the partner center URL of a newly created label.
+func reportCreated(productID, submissionID string, respObj map[string]any) {
+	if id, ok := support.TryGetInt64(respObj, "id"); ok {
+		ui.Ok("Created: " + labelURL(productID, submissionID, fmt.Sprint(id)))
 	} else {
-		fmt.Println("")
-		selected, err = selectTargets(parsed, opt)
-		if err != nil {
-			printErr(err)
-			return exitCode(err)
-		}
-		if len(selected) == 0 {
-			ui.Fail("No hardwareIds selected")
-			return 1
-		}
-		ui.Ok(fmt.Sprintf("Selected %d hardwareIds", len(selected)))
+		ui.Ok("Created (id not found in response)")
 	}
-	ui.EndLine("Selected")
+}
 
-	// ---- Step 4: Create Label ----
-	ui.Section(ui.StepCtx{Title: "Create Shipping Label", Current: 4, Total: 4})
+// collectCHIDs takes CHIDs from --chids, from projects picked in the CHID
+// registry, or prompts until the input is valid. With required=false an
+// empty answer is accepted. CHIDs missing from the registry are warned
+// about.
+func collectCHIDs(opt *cli.CLIOptions, required bool) ([]string, error) {
+	reg, err := chid.LoadRegistry(chidRegistryPath(opt))
+	if err != nil {
+		ui.Warn("CHID registry not loaded: " + err.Error())
+		reg = &chid.Registry{}
+	}
 
-	name := opt.Name
-	if support.IsBlank(name) {
-		name = ui.Prompt("Shipping label name", "{OEM Name}: {Project Name}")
-		if name == "{OEM Name}: {Project Name}" {
-			// handled by prompt returning default if user hits enter
+	if err := loadCHIDsFile(opt); err != nil {
+		return nil, err
+	}
+	if len(opt.Chids) > 0 {
+		chids, err := validate.NormalizeCHIDsRequired(opt.Chids)
+		if err == nil {
+			warnUnregisteredCHIDs(reg, chids)
 		}
+		return chids, err
 	}
 
+	if chids, err := pickCHIDProjects(reg); err != nil || len(chids) > 0 {
+		return chids, err
+	}
+
+	// Valid CHIDs are kept across attempts; only rejected ones need to be
+	// entered again.
+	label := "CHIDs (Required, comma separated, or paste ComputerHardwareIds output)"
+	if !required {
+		label = "CHIDs (Optional, comma separated, or paste ComputerHardwareIds output)"
+	}
 	var chids []string
-	if len(opt.Chids) > 0 {
-		chids, err = validate.NormalizeCHIDsRequired(opt.Chids)
-		if err != nil {
-			printErr(err)
-			return exitCode(err)
-		}
-	} else {
-		for {
-			raw := ui.Prompt("CHIDs (Required, comma separated)", "")
-			parts := []string{}
-			for _, p := range strings.Split(raw, ",") {
-				p = strings.TrimSpace(p)
-				if p != "" {
-					parts = append(parts, p)
-				}
+	for {
+		raw := ui.Prompt(label, "")
+		if raw == "" {
+			if len(chids) > 0 || !required {
+				break
 			}
+			ui.ErrorInside("至少需要 1 个 CHID。")
+			continue
+		}
+		if startsCHIDBlock(raw) {
+			// ComputerHardwareIds output has blank lines between its
+			// sections; the paste ends at a blank line after the GUIDs.
+			first := raw
+			raw += "\n" + ui.PromptMoreLines("Paste the rest, then press Enter on an empty line", func(text string) bool {
+				return len(validate.ExtractCHIDs(first+"\n"+text).CHIDs) > 0
+			})
+		}
 
-			chids, err = validate.NormalizeCHIDsRequired(parts)
-			if err == nil {
-				break
+		ex := validate.ExtractCHIDs(raw)
+		reportExtractedCHIDs("", ex)
+		for _, c := range ex.CHIDs {
+			if !slices.Contains(chids, c) {
+				chids = append(chids, c)
 			}
-			ui.ErrorInside(err.Error())
+		}
+		if len(ex.Rejected) == 0 && len(chids) > 0 {
+			break
+		}
+		if len(ex.Rejected) > 0 {
+			label = "Re-enter the rejected CHIDs (blank to continue)"
+		} else if len(chids) == 0 && required {
+			ui.ErrorInside("至少需要 1 个 CHID。")
 		}
 	}
-
-	bodyObj, err := shippinglabel.BuildPayload(opt, name, selected, chids)
-	if err != nil {
-		printErr(err)
-		return exitCode(err)
+	if chids == nil {
+		chids = []string{}
 	}
+	warnUnregisteredCHIDs(reg, chids)
+	return chids, nil
+}
 
-	outPath := opt.OutPath
-	if support.IsBlank(outPath) {
-		outPath = "shippinglabel.request.json"
+// pickCHIDProjects offers the registry's projects by name and returns the
+// union of the picked projects' CHIDs. Nothing picked means the CHIDs are
+// typed instead.
+func pickCHIDProjects(reg *chid.Registry) ([]string, error) {
+	if len(reg.Projects) == 0 {
+		return nil, nil
 	}
-	if err := os.WriteFile(outPath, format.MustJSONIndent(bodyObj), 0644); err != nil {
-		ui.Fail("Failed to write request body: " + err.Error())
-		return 1
+	texts := make([]string, 0, len(reg.Projects))
+	for _, p := range reg.Projects {
+		texts = append(texts, fmt.Sprintf("%s  (%d CHIDs)", p.Name, len(p.CHIDs)))
 	}
-	ui.Ok("Request saved: " + outPath)
+	idxs, err := cli.PromptIndexSelection("Select projects from the CHID registry (Enter to type CHIDs)", texts, true, true)
+	if err != nil || len(idxs) == 0 {
+		return nil, err
+	}
+	var all []string
+	for _, i := range idxs {
+		all = append(all, reg.Projects[i].GUIDs()...)
+		ui.Info(fmt.Sprintf("%s: %d CHIDs", reg.Projects[i].Name, len(reg.Projects[i].CHIDs)))
+	}
+	return validate.NormalizeCHIDsRequired(all)
+}
 
-	if opt.DryRun {
-		ui.EndLine("--dry-run (no POST)")
-		return 0
+// warnUnregisteredCHIDs flags CHIDs no registry project lists, which are
+// often typos.
+func warnUnregisteredCHIDs(reg *chid.Registry, chids []string) {
+	if len(reg.Projects) == 0 {
+		return
+	}
+	for _, c := range chids {
+		if len(reg.Owners(c)) == 0 {
+			ui.Warn("CHID not in the registry: " + c)
+		}
 	}
+}
 
-	var respObj map[string]any
-	err = ui.Spin("Creating shipping label...", func() error {
-		var e error
-		respObj, e = devcenter.CreateShippingLabel(ctx, httpClient, token, opt.ProductID, opt.SubmissionID, bodyObj)
-		return e
-	})
+// askOptional reports whether to offer the optional questions (release
+// range, audiences). Only a user at a terminal is asked, and not when the
+// targeting is already given by flags, as in a scripted run: there a
+// missing flag means "none".
+func askOptional(opt *cli.CLIOptions) bool {
+	if !terminal.Current().Interactive() {
+		return false
+	}
+	return len(opt.Chids) == 0 && support.IsBlank(opt.CHIDsFile) &&
+		support.IsBlank(opt.FloorOS) && support.IsBlank(opt.CeilingOS) && len(opt.Audiences) == 0
+}
 
-	if err != nil {
-		printErr(err)
-		return exitCode(err)
+// promptInServiceRange asks for an optional floor/ceiling Windows release.
+func promptInServiceRange(opt *cli.CLIOptions) {
+	if !askOptional(opt) || !ui.PromptYesNo("Limit the label to a range of Windows releases (floor/ceiling)?", false) {
+		return
 	}
+	ui.Info("Releases: " + strings.Join(drivermeta.ReleaseTokens(), ", ") + " (versions like 22H2 also work)")
+	opt.FloorOS = ui.Prompt("Floor release (blank for none)", "")
+	opt.CeilingOS = ui.Prompt("Ceiling release (blank for none)", "")
+}
 
-	if id, ok := support.TryGetInt64(respObj, "id"); ok {
-		shippingURL := fmt.Sprintf(partnerShippingURLTemplate, opt.ProductID, opt.SubmissionID, id)
-		ui.Ok("Created: " + shippingURL)
-	} else {
-		ui.Ok("Created (id not found in response)")
+// reportInvalid prints each validation problem inside the current section.
+func reportInvalid(err error) {
+	ui.Fail("Shipping label request is invalid")
+	var verr *shippinglabel.ValidationError
+	if errors.As(err, &verr) {
+		for _, p := range verr.Problems {
+			ui.ErrorInside(p)
+		}
+		return
 	}
+	printErr(err)
+}
 
-	ui.EndLine("Complete")
-	ui.Prompt("Press Enter to exit", "")
-	return 0
+// applyOSRules narrows targets by --os-filter and, with --group-by-os,
+// reorders them so each OS forms one contiguous block.
+func applyOSRules(targets []drivermeta.HardwareTarget, opt *cli.CLIOptions) ([]drivermeta.HardwareTarget, error) {
+	out := targets
+	if !support.IsBlank(opt.OSFilter) {
+		f, err := drivermeta.ParseOSFilter(opt.OSFilter)
+		if err != nil {
+			return nil, err
+		}
+		out = drivermeta.FilterTargetsByOS(out, f)
+		ui.Ok(fmt.Sprintf("--os-filter: %d/%d candidates", len(out), len(targets)))
+	}
+	if opt.GroupByOS {
+		out = drivermeta.SortTargetsByOS(out)
+		for _, g := range drivermeta.GroupTargetsByOS(out) {
+			fmt.Printf("  %-28s %5d  %s\n", g.Info.FriendlyName(), len(g.Targets), g.Info.Code)
+		}
+	}
+	return out, nil
 }
 
-func selectTargets(parsed *drivermeta.ParseResult, opt *cli.CLIOptions) ([]drivermeta.HardwareTarget, error) {
-	working := parsed.Targets
+func selectTargets(parsed *drivermeta.ParseResult, candidates []drivermeta.HardwareTarget, opt *cli.CLIOptions) ([]drivermeta.HardwareTarget, error) {
+	working := candidates
 
 	// offer filter if too many (same behavior)
 	if len(working) > 300 && opt.OfferFilter {
 		if ui.PromptYesNo(fmt.Sprintf("Too many candidates (%d). Filter by keyword?", len(working)), true) {
-			kw := ui.Prompt("Filter keyword (INF/OS/PNP...)", "")
-			if !support.IsBlank(kw) {
+			kw := ui.Prompt("Filter keyword (INF/OS/PNP... or arch=x64 release>=21H2)", "")
+			// A mistyped OS filter is asked again rather than ending the run.
+			for drivermeta.LooksLikeOSFilter(kw) {
+				f, err := drivermeta.ParseOSFilter(kw)
+				if err == nil {
+					working = drivermeta.FilterTargetsByOS(working, f)
+					break
+				}
+				ui.ErrorInside(err.Error())
+				kw = ui.Prompt("Filter keyword (INF/OS/PNP... or arch=x64 release>=21H2)", "")
+			}
+			if !drivermeta.LooksLikeOSFilter(kw) && !support.IsBlank(kw) {
 				low := support.ToLower(kw)
 				tmp := make([]drivermeta.HardwareTarget, 0, len(working))
 				for _, t := range working {
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"os"
+
+	"WU/internal/auth"
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+// session is a Dev Center connection that authenticates on first use, so
+// commands working only on local files never ask for credentials.
+type session struct {
+	opt    *cli.CLIOptions
+	ctx    context.Context
+	client *devcenter.Client
+	token  string
+}
+
+func newSession(ctx context.Context, opt *cli.CLIOptions) *session {
+	return &session{opt: opt, ctx: ctx, client: devcenter.NewClient(baseAPI)}
+}
+
+// Token returns the access token, acquiring it the first time.
+func (s *session) Token() (string, error) {
+	if s.token != "" {
+		return s.token, nil
+	}
+	resolveCredentials(s.opt)
+	if support.IsBlank(s.opt.TenantID) || support.IsBlank(s.opt.ClientID) || support.IsBlank(s.opt.ClientSecret) {
+		return "", support.NewAPIError("tenant_id / client_id / client_secret 不能为空")
+	}
+
+	var token string
+	err := ui.Spin("Acquiring token...", func() error {
+		var err error
+		token, err = auth.AcquireToken(s.ctx, s.client.HTTP, s.opt.TenantID, s.opt.ClientID, s.opt.ClientSecret)
+		return err
+	})
+	if err != nil {
+		ui.Fail("Token acquisition failed")
+		return "", err
+	}
+	ui.Ok("Token acquired")
+	s.token = token
+	return token, nil
+}
+
+// resolveCredentials fills tenant/client/secret from credential.json, flags,
+// the environment or prompts, and saves them back next to the executable.
+func resolveCredentials(opt *cli.CLIOptions) {
+	credPath := credentialPath()
+	cred := auth.LoadCredential(credPath)
+
+	// CLI/env override > credential.json
+	opt.TenantID = support.FirstNonEmpty(cred.TenantID, opt.TenantID, os.Getenv("HW_TENANT_ID"))
+	opt.ClientID = support.FirstNonEmpty(cred.ClientID, opt.ClientID, os.Getenv("HW_CLIENT_ID"))
+	opt.ClientSecret = support.FirstNonEmpty(cred.ClientSecret, opt.ClientSecret, os.Getenv("HW_CLIENT_SECRET"))
+
+	// Prompt if missing
+	if support.IsBlank(opt.TenantID) {
+		opt.TenantID = ui.Prompt("tenant_id", "")
+	}
+	if support.IsBlank(opt.ClientID) {
+		opt.ClientID = ui.Prompt("client_id", "")
+	}
+	if support.IsBlank(opt.ClientSecret) {
+		opt.ClientSecret = ui.PromptSecret("client_secret")
+	}
+
+	// Save back
+	cred.TenantID = opt.TenantID
+	cred.ClientID = opt.ClientID
+	cred.ClientSecret = opt.ClientSecret
+	auth.SaveCredential(credPath, cred)
+}
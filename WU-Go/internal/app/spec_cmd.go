@@ -0,0 +1,272 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/drivermeta"
+	"WU/internal/labelspec"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/terminal"
+	"WU/internal/ui"
+)
+
+const planUsage = `Usage:
+  wu plan -f <labels.yaml> [--product-id <id> --submission-id <id>]
+
+Compares the labels described in the file with the shipping labels on the
+submission and prints what wu apply would create or update. The file is YAML
+(or JSON when it ends in .json):
+
+  productId: "13635057"
+  submissionId: "1152921505694035346"
+  defaults:
+    isAutoInstallOnApplicableSystems: true
+    microsoftContact: someone@microsoft.com
+    chids: [5b4e5bf1-a6ad-5e24-8c43-fd4b4f9b3fd3]
+  labels:
+    - name: "OEM: Project (Windows 11)"
+      targets:
+        osFilter: windows=11
+        bundles: [B1]
+        pnp: ["PCI\\VEN_8086*"]
+    - name: "OEM: Project (pilot)"
+      targets: {all: true}
+      audiences: [pilot]
+
+Label options override defaults, which override command-line options. Labels
+are matched to the server by name; labels not in the file are left alone.`
+
+const applyUsage = `Usage:
+  wu apply -f <labels.yaml> [--yes] [--allow-conflicts]
+
+Computes the same plan as wu plan and carries it out: missing labels are
+created and changed ones are updated. Asks for confirmation unless --yes is
+given. After a failure, run it again; the plan only contains what is left.`
+
+func runPlan(opt *cli.CLIOptions) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	s := newSession(ctx, opt)
+
+	sp, code := loadPlan(s)
+	if code != 0 {
+		return code
+	}
+	printPlan(sp.plan)
+	return 0
+}
+
+func runApply(opt *cli.CLIOptions) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	s := newSession(ctx, opt)
+
+	sp, code := loadPlan(s)
+	if code != 0 {
+		return code
+	}
+	plan := sp.plan
+	printPlan(plan)
+	if plan.Pending() == 0 {
+		ui.EndLine("Nothing to apply")
+		return 0
+	}
+
+	if !opt.Yes {
+		if !terminal.Current().Interactive() {
+			ui.Fail("Refusing to apply without a terminal; pass --yes")
+			return 2
+		}
+		if !ui.PromptYesNo(fmt.Sprintf("Apply %d change(s)?", plan.Pending()), false) {
+			ui.EndLine("Cancelled")
+			return 1
+		}
+	}
+
+	var pending []*shippinglabel.Request
+	for _, st := range plan.Steps {
+		if st.Action != labelspec.ActionUnchanged {
+			pending = append(pending, st.Body)
+		}
+	}
+	if code := checkConflicts(s, pending, drivermeta.DriverVersions(sp.parsed.Targets)); code != 0 {
+		return code
+	}
+
+	rec := startRecorder(opt, "apply", sp.submission, sp.metaRoot)
+	defer rec.done()
+
+	for _, st := range plan.Steps {
+		var err error
+		switch st.Action {
+		case labelspec.ActionCreate:
+			var respObj map[string]any
+			var existed bool
+			respObj, existed, err = postLabel(s, st.Body, rec)
+			if err == nil && !existed {
+				reportCreated(opt.ProductID, opt.SubmissionID, respObj)
+			}
+		case labelspec.ActionUpdate:
+			id := st.Existing.ID.String()
+			var respObj map[string]any
+			err = ui.Spin("Updating "+st.Body.Name+"...", func() error {
+				var e error
+				respObj, e = devcenter.UpdateShippingLabel(s.ctx, s.client, s.token, opt.ProductID, opt.SubmissionID, id, st.Body)
+				return e
+			})
+			rec.record("update", st.Body, id, respObj, err)
+			if err == nil {
+				ui.Ok("Updated: " + labelURL(opt.ProductID, opt.SubmissionID, id))
+			}
+		}
+		if err != nil {
+			ui.Fail(st.Body.Name)
+			printErr(err)
+			ui.Info("Run wu apply again to continue with the remaining changes")
+			return exitCode(err)
+		}
+	}
+	ui.EndLine("Applied")
+	return 0
+}
+
+// specPlan is a computed plan and the submission data it was built from.
+type specPlan struct {
+	plan       *labelspec.Plan
+	submission map[string]any
+	metaRoot   map[string]any
+	parsed     *drivermeta.ParseResult
+}
+
+// loadPlan reads the spec, builds and validates the wanted labels against
+// the submission's driverMetadata and compares them with the server.
+func loadPlan(s *session) (*specPlan, int) {
+	opt := s.opt
+	if support.IsBlank(opt.SpecFile) {
+		ui.Fail("-f <labels.yaml> is required")
+		return nil, 2
+	}
+	spec, err := labelspec.Load(opt.SpecFile)
+	if err != nil {
+		printErr(err)
+		return nil, exitCode(err)
+	}
+	opt.ProductID = support.FirstNonEmpty(opt.ProductID, spec.ProductID)
+	opt.SubmissionID = support.FirstNonEmpty(opt.SubmissionID, spec.SubmissionID)
+	promptSubmissionIDs(opt)
+	if support.IsBlank(opt.ProductID) || support.IsBlank(opt.SubmissionID) {
+		ui.Fail("product_id / submission_id cannot be empty")
+		return nil, 2
+	}
+
+	submission, metaRoot, err := fetchSubmissionMetadata(s, opt.ProductID, opt.SubmissionID)
+	if err != nil {
+		printErr(err)
+		return nil, exitCode(err)
+	}
+	parsed, err := drivermeta.Parse(metaRoot)
+	if err != nil {
+		printErr(err)
+		return nil, exitCode(err)
+	}
+
+	want, err := spec.Build(opt, parsed.Targets)
+	if err != nil {
+		printErr(err)
+		return nil, exitCode(err)
+	}
+	if code := resolveSpecAudiences(s, want); code != 0 {
+		return nil, code
+	}
+	for _, req := range want {
+		if err := shippinglabel.Validate(req, time.Now()); err != nil {
+			ui.Fail("Label " + req.Name)
+			reportInvalid(err)
+			return nil, 1
+		}
+	}
+	ui.Ok(fmt.Sprintf("Spec OK: %d label(s)", len(want)))
+
+	var items []map[string]any
+	err = ui.Spin("Fetching shipping labels...", func() error {
+		var e error
+		items, e = devcenter.ListShippingLabels(s.ctx, s.client, s.token, opt.ProductID, opt.SubmissionID)
+		return e
+	})
+	if err != nil {
+		printErr(err)
+		return nil, exitCode(err)
+	}
+	var existing []shippinglabel.Label
+	for _, it := range items {
+		l, err := shippinglabel.DecodeLabel(it)
+		if err != nil {
+			printErr(err)
+			return nil, exitCode(err)
+		}
+		existing = append(existing, *l)
+	}
+
+	return &specPlan{
+		plan:       labelspec.Compare(want, existing),
+		submission: submission,
+		metaRoot:   metaRoot,
+		parsed:     parsed,
+	}, 0
+}
+
+// resolveSpecAudiences turns audience names in the spec into IDs, fetching
+// the account's audiences only when a label uses them.
+func resolveSpecAudiences(s *session, reqs []*shippinglabel.Request) int {
+	var list []shippinglabel.Audience
+	for _, req := range reqs {
+		if len(req.Targeting.RestrictedToAudiences) == 0 {
+			continue
+		}
+		if list == nil {
+			var err error
+			if list, err = fetchAudiences(s); err != nil {
+				printErr(err)
+				return exitCode(err)
+			}
+		}
+		ids, err := resolveAudiences(list, req.Targeting.RestrictedToAudiences)
+		if err != nil {
+			ui.Fail("Label " + req.Name)
+			printErr(err)
+			return exitCode(err)
+		}
+		req.SetAudiences(ids)
+	}
+	return 0
+}
+
+func printPlan(p *labelspec.Plan) {
+	var create, update, same int
+	for _, st := range p.Steps {
+		switch st.Action {
+		case labelspec.ActionCreate:
+			create++
+			fmt.Printf("  + create     %q (hardwareIds=%d, chids=%d)\n",
+				st.Body.Name, len(st.Body.Targeting.HardwareIDs), len(st.Body.Targeting.Chids))
+		case labelspec.ActionUpdate:
+			update++
+			fmt.Printf("  ~ update     %q (%s)\n", st.Body.Name, st.Existing.ID)
+			for _, c := range st.Changes {
+				fmt.Printf("      %s\n", c)
+			}
+		default:
+			same++
+			fmt.Printf("  = unchanged  %q\n", st.Body.Name)
+		}
+	}
+	for _, l := range p.Unmanaged {
+		fmt.Printf("  ? unmanaged  %q (%s), not in the spec, left as is\n", l.Name, l.ID)
+	}
+	ui.Info(fmt.Sprintf("Plan: %d to create, %d to update, %d unchanged", create, update, same))
+}
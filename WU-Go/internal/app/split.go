@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/drivermeta"
+	"WU/internal/labelspec"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/ui"
+)
+
+// buildLabelBodies builds one request per part of the selection
+// (--split-by / --max-targets). Without splitting it returns a single body
+// named exactly as entered.
+func buildLabelBodies(opt *cli.CLIOptions, name string, selected []drivermeta.HardwareTarget, chids []string) ([]*shippinglabel.Request, error) {
+	parts, err := shippinglabel.SplitTargets(selected, opt.SplitBy, opt.MaxTargets)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 1 {
+		return []*shippinglabel.Request{shippinglabel.BuildPayload(opt, name, parts[0].Targets, chids)}, nil
+	}
+
+	tmpl := support.Or(opt.NameTemplate, shippinglabel.DefaultNameTemplate)
+	bodies := make([]*shippinglabel.Request, 0, len(parts))
+	seen := map[string]int{}
+	for i, p := range parts {
+		labelName := shippinglabel.ExpandNameTemplate(tmpl, name, p, i+1, len(parts))
+		// Names are how a re-run recognises labels it already created.
+		if j, dup := seen[labelKey(labelName)]; dup {
+			return nil, support.NewAPIError(fmt.Sprintf("--name-template 生成了重复的名称 %q（第 %d 与第 %d 个标签），请加入 {index}", labelName, j+1, i+1))
+		}
+		seen[labelKey(labelName)] = i
+		bodies = append(bodies, shippinglabel.BuildPayload(opt, labelName, p.Targets, chids))
+	}
+	return bodies, nil
+}
+
+func labelKey(name string) string { return strings.ToLower(strings.TrimSpace(name)) }
+
+// printSplitSummary shows the shared settings once and one line per label.
+func printSplitSummary(bodies []*shippinglabel.Request) {
+	var all []shippinglabel.HardwareID
+	for _, b := range bodies {
+		all = append(all, b.Targeting.HardwareIDs...)
+	}
+	ui.Info(fmt.Sprintf("Selection split into %d labels", len(bodies)))
+	for i, b := range bodies {
+		fmt.Printf("  [%d/%d] %-60s hardwareIds=%d\n", i+1, len(bodies), b.Name, len(b.Targeting.HardwareIDs))
+	}
+	first := *bodies[0]
+	first.Name = "(per label, see above)"
+	first.Targeting.HardwareIDs = all
+	printRequestSummary(&first)
+}
+
+// splitOutPath numbers the output file of each label when there are
+// several: shippinglabel.request.json -> shippinglabel.request.2.json
+// (zero-padded once there are ten or more).
+func splitOutPath(path string, i, n int) string {
+	if n <= 1 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	width := len(fmt.Sprint(n))
+	return fmt.Sprintf("%s.%0*d%s", strings.TrimSuffix(path, ext), width, i+1, ext)
+}
+
+// createSplitLabels creates the labels in order. Labels that already exist
+// on the submission with the same name and content are skipped, so
+// re-running the same command after a partial failure picks up where it
+// stopped. A label of that name with other content (the selection or the
+// template changed since) is reported as a conflict and not counted as
+// done.
+func createSplitLabels(sess *session, bodies []*shippinglabel.Request, rec *recorder) int {
+	opt := sess.opt
+	token, err := sess.Token()
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+
+	var existing []map[string]any
+	err = ui.Spin("Checking existing shipping labels...", func() error {
+		var e error
+		existing, e = devcenter.ListShippingLabels(sess.ctx, sess.client, token, opt.ProductID, opt.SubmissionID)
+		return e
+	})
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+	byName := map[string]*shippinglabel.Label{}
+	for _, it := range existing {
+		if l, err := shippinglabel.DecodeLabel(it); err == nil {
+			byName[labelKey(l.Name)] = l
+		}
+	}
+
+	status := make([]string, len(bodies))
+	var failed error
+	conflicts := 0
+	for i, b := range bodies {
+		if failed != nil {
+			status[i] = "not attempted"
+			continue
+		}
+		if l, ok := byName[labelKey(b.Name)]; ok {
+			url := labelURL(opt.ProductID, opt.SubmissionID, l.ID.String())
+			if changes := labelspec.Changes(b, l); len(changes) > 0 {
+				status[i] = fmt.Sprintf("CONFLICT, exists with other content (%s) %s", strings.Join(changes, "; "), url)
+				conflicts++
+				continue
+			}
+			status[i] = "exists " + url
+			continue
+		}
+		ui.Info(fmt.Sprintf("Label %d/%d", i+1, len(bodies)))
+		respObj, _, err := postLabel(sess, b, rec)
+		if err != nil {
+			failed = err
+			status[i] = "FAILED"
+			continue
+		}
+		if id, ok := support.TryGetInt64(respObj, "id"); ok {
+			status[i] = "created " + labelURL(opt.ProductID, opt.SubmissionID, fmt.Sprint(id))
+		} else {
+			status[i] = "created (id not found in response)"
+		}
+	}
+
+	ui.Info("Shipping labels")
+	for i, b := range bodies {
+		fmt.Printf("  [%d/%d] %s: %s\n", i+1, len(bodies), b.Name, status[i])
+	}
+	if failed != nil {
+		printErr(failed)
+		ui.Fail("Stopped after a failure; re-run the same command to create the remaining labels")
+		return exitCode(failed)
+	}
+	if conflicts > 0 {
+		ui.Fail(fmt.Sprintf("%d label(s) already exist with other content; change --name-template or update them with wu plan/apply", conflicts))
+		return 1
+	}
+	ui.Ok(fmt.Sprintf("All %d labels in place", len(bodies)))
+	return 0
+}
+
+// labelURL is the partner center page of a label.
+func labelURL(productID, submissionID, labelID string) string {
+	return fmt.Sprintf(partnerShippingURLTemplate, productID, submissionID, labelID)
+}
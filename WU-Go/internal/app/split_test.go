@@ -0,0 +1,45 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"WU/internal/shippinglabel"
+)
+
+func TestCreateSplitLabelsResumes(t *testing.T) {
+	s, f, _ := newFakeSession(t)
+	bodies := []*shippinglabel.Request{
+		testLabel("Project (1/2)", `PCI\VEN_8086&DEV_0001`),
+		testLabel("Project (2/2)", `PCI\VEN_8086&DEV_0002`),
+	}
+	f.addLabel(bodies[0]) // created by the run that failed
+
+	if code := createSplitLabels(s, bodies, nil); code != 0 {
+		t.Fatalf("createSplitLabels() = %d", code)
+	}
+	if !slices.Equal(f.posts, []string{"Project (2/2)"}) {
+		t.Fatalf("posted %q, want only the missing label", f.posts)
+	}
+}
+
+func TestCreateSplitLabelsReportsChangedLabelsAsConflicts(t *testing.T) {
+	s, f, v := newFakeSession(t)
+	// the selection changed since: the first label now targets another device
+	f.addLabel(testLabel("Project (1/2)", `PCI\VEN_8086&DEV_0001`))
+	bodies := []*shippinglabel.Request{
+		testLabel("Project (1/2)", `PCI\VEN_8086&DEV_0009`),
+		testLabel("Project (2/2)", `PCI\VEN_8086&DEV_0002`),
+	}
+
+	if code := createSplitLabels(s, bodies, nil); code != 1 {
+		t.Fatalf("createSplitLabels() = %d, want 1", code)
+	}
+	if !slices.Equal(f.posts, []string{"Project (2/2)"}) {
+		t.Fatalf("posted %q, want only the missing label", f.posts)
+	}
+	if screen := v.Screen(); !strings.Contains(screen, "1 label(s) already exist with other content") {
+		t.Errorf("conflict not reported:\n%s", screen)
+	}
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"WU/internal/chid"
+	"WU/internal/cli"
+	"WU/internal/devcenter"
+	"WU/internal/drivermeta"
+	"WU/internal/format"
+	"WU/internal/history"
+	"WU/internal/labelindex"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/terminal"
+	"WU/internal/ui"
+)
+
+const whereisUsage = `Usage:
+  wu whereis --chid <guid> | --hwid <hardware id> [--product-id <id>]
+             [--refresh | --offline] [--format text|json]
+
+Lists every shipping label that targets a CHID and/or hardware ID, with the
+driver version it ships, its workflow state and go-live date. Both may be
+given to narrow the search to labels targeting the pair.
+
+Labels are looked up in a local index of all products, submissions and
+labels (label-index.json under --history-dir). The index is refreshed
+when it is older than an hour: products, submissions and labels are
+always listed again (a new or edited label does not change its
+submission), and only driverMetadata is cached: it is downloaded for
+submissions that are new or changed. A product or submission that cannot
+be listed keeps its previous entries and is reported; the rest is saved.
+With --format json, refresh progress goes to stderr.
+
+  --chid <guid>          CHID of the machine
+  --hwid <text>          Hardware ID; a device's full ID also matches labels
+                         with a more generic PnP string, and partial IDs
+                         match any PnP string containing them
+  --product-id <id>      Only this product (refreshes only this product)
+  --refresh              Refresh the index now
+  --offline              Use the index as is, without connecting`
+
+// indexMaxAge is how old the label index may get before whereis refreshes
+// it on its own.
+const indexMaxAge = time.Hour
+
+// whereisMatch is the --format json shape of a match.
+type whereisMatch struct {
+	ProductID      string   `json:"productId"`
+	ProductName    string   `json:"productName,omitempty"`
+	SubmissionID   string   `json:"submissionId"`
+	SubmissionName string   `json:"submissionName,omitempty"`
+	LabelID        string   `json:"labelId"`
+	LabelName      string   `json:"labelName"`
+	Destination    string   `json:"destination"`
+	Step           string   `json:"currentStep,omitempty"`
+	State          string   `json:"state,omitempty"`
+	GoLiveDate     string   `json:"goLiveDate,omitempty"`
+	DriverVersions []string `json:"driverVersions"`
+	PnpIDs         []string `json:"pnpIds"`
+	URL            string   `json:"url"`
+}
+
+func runWhereis(opt *cli.CLIOptions) int {
+	if err := checkFormat(opt.Format, "text", "json"); err != nil {
+		printErr(err)
+		return 2
+	}
+	if support.IsBlank(opt.CHID) && support.IsBlank(opt.HWID) {
+		fmt.Println(whereisUsage)
+		return 2
+	}
+	if !support.IsBlank(opt.CHID) && chid.Normalize(opt.CHID) == "" {
+		printErr(support.NewAPIError("CHID 不是合法 GUID（需 8-4-4-4-12 且带连字符）: " + opt.CHID))
+		return 2
+	}
+
+	path := filepath.Join(historyDir(opt), labelindex.File)
+	ix, err := labelindex.Load(path)
+	if err != nil {
+		printErr(err)
+		return exitCode(err)
+	}
+
+	if opt.Offline && ix.Updated.IsZero() {
+		ui.Fail("No label index yet at " + path + "; run once without --offline")
+		return 1
+	}
+	stale := time.Since(ix.Updated) > indexMaxAge
+	if !opt.Offline && (opt.Refresh || stale) {
+		if opt.Format == "json" {
+			// Keep stdout to the JSON, so it can be piped.
+			defer terminal.Use(terminal.Stderr{})()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		if err := refreshLabelIndex(newSession(ctx, opt), ix); err != nil {
+			printErr(err)
+			return exitCode(err)
+		}
+		if err := ix.Save(path); err != nil {
+			ui.Warn("Label index not saved: " + err.Error())
+		}
+	} else if opt.Format != "json" {
+		ui.Info(fmt.Sprintf("Using label index from %s (%s)", ix.Updated.Local().Format("2006-01-02 15:04"), path))
+	}
+
+	found := ix.Find(labelindex.Query{CHID: opt.CHID, HWID: opt.HWID, ProductID: opt.ProductID})
+	if opt.Format == "json" {
+		out := make([]whereisMatch, 0, len(found))
+		for _, m := range found {
+			out = append(out, toWhereisMatch(m))
+		}
+		os.Stdout.Write(append(format.MustJSONIndent(out), '\n'))
+		return 0
+	}
+
+	if !support.IsBlank(opt.CHID) {
+		if reg, err := chid.LoadRegistry(chidRegistryPath(opt)); err == nil {
+			if owners := reg.Owners(opt.CHID); len(owners) > 0 {
+				ui.Info("CHID registered for: " + strings.Join(owners, ", "))
+			}
+		}
+	}
+	if len(found) == 0 {
+		ui.Info(fmt.Sprintf("No label targets it (%d submissions indexed)", len(ix.Submissions)))
+		return 0
+	}
+	ui.Ok(fmt.Sprintf("%d label(s)", len(found)))
+	for _, m := range found {
+		w := toWhereisMatch(m)
+		ui.Item(fmt.Sprintf("%s  %s", w.LabelID, w.LabelName))
+		fmt.Printf("    product:     %s %s\n", w.ProductID, w.ProductName)
+		fmt.Printf("    submission:  %s %s\n", w.SubmissionID, w.SubmissionName)
+		fmt.Printf("    driver:      %s\n", support.Or(strings.Join(w.DriverVersions, ", "), "unknown"))
+		fmt.Printf("    workflow:    step=%s state=%s\n", w.Step, w.State)
+		if w.Destination != shippinglabel.DestinationAnotherPartner {
+			fmt.Printf("    goLiveDate:  %s\n", support.Or(w.GoLiveDate, "(immediate)"))
+		} else {
+			fmt.Printf("    destination: %s\n", w.Destination)
+		}
+		const maxShown = 3
+		for i, p := range w.PnpIDs {
+			if i == maxShown {
+				fmt.Printf("    ... and %d more PnP ID(s)\n", len(w.PnpIDs)-maxShown)
+				break
+			}
+			fmt.Printf("    pnp:         %s\n", p)
+		}
+		fmt.Printf("    %s\n", w.URL)
+	}
+	return 0
+}
+
+func toWhereisMatch(m labelindex.Match) whereisMatch {
+	l := m.Label
+	w := whereisMatch{
+		ProductID:      m.ProductID,
+		ProductName:    m.ProductName,
+		SubmissionID:   m.SubmissionID,
+		SubmissionName: m.SubmissionName,
+		LabelID:        l.ID.String(),
+		LabelName:      l.Name,
+		Destination:    l.Destination,
+		DriverVersions: append([]string{}, m.DriverVersions...),
+		PnpIDs:         []string{},
+		URL:            labelURL(m.ProductID, m.SubmissionID, l.ID.String()),
+	}
+	if s := l.WorkflowStatus; s != nil {
+		w.Step, w.State = s.CurrentStep, s.State
+	}
+	if p := l.PublishingSpecifications; p != nil {
+		w.GoLiveDate = p.GoLiveDate
+	}
+	for _, h := range m.HardwareIDs {
+		if !slices.Contains(w.PnpIDs, h.PnpString) {
+			w.PnpIDs = append(w.PnpIDs, h.PnpString)
+		}
+	}
+	return w
+}
+
+// refreshLabelIndex lists products, submissions and labels again. Labels
+// are always listed, as adding or editing one leaves its submission's
+// status as it was; only the driver versions are cached, kept while a
+// submission's workflow status is unchanged, so only new or changed
+// submissions download driverMetadata. A product or submission that
+// cannot be listed keeps its previous entries and is reported, so the
+// rest of the crawl is not lost; only when no product could be listed is
+// the error returned.
+func refreshLabelIndex(s *session, ix *labelindex.Index) error {
+	token, err := s.Token()
+	if err != nil {
+		return err
+	}
+
+	var products []map[string]any
+	if pid := s.opt.ProductID; !support.IsBlank(pid) {
+		products = []map[string]any{{"id": pid}}
+	} else {
+		err = ui.Spin("Listing products...", func() error {
+			var e error
+			products, e = devcenter.ListProducts(s.ctx, s.client, token)
+			return e
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	next := map[string]*labelindex.Submission{}
+	// keep carries previous entries over: the other products when only one
+	// is refreshed, and whatever could not be listed this time.
+	keep := func(match func(*labelindex.Submission) bool) {
+		for k, sub := range ix.Submissions {
+			if match(sub) {
+				next[k] = sub
+			}
+		}
+	}
+	if pid := s.opt.ProductID; !support.IsBlank(pid) {
+		keep(func(sub *labelindex.Submission) bool { return sub.ProductID != pid })
+	}
+
+	var reused, downloaded, failed int
+	var unlisted []string
+	var firstErr error
+	productsFailed := 0
+	for i, p := range products {
+		pid := fmt.Sprint(p["id"])
+		pname, _ := p["productName"].(string)
+		err := ui.Spin(fmt.Sprintf("Indexing product %s (%d/%d)...", pid, i+1, len(products)), func() error {
+			subs, err := devcenter.ListSubmissions(s.ctx, s.client, token, pid)
+			if err != nil {
+				return err
+			}
+			for _, sub := range subs {
+				sid := fmt.Sprint(sub["id"])
+				sname, _ := sub["name"].(string)
+				key := labelindex.Key(pid, sid)
+				entry := &labelindex.Submission{
+					ProductID: pid, ProductName: pname,
+					SubmissionID: sid, SubmissionName: sname,
+					Fingerprint: history.SHA256(map[string]any{
+						"workflowStatus": sub["workflowStatus"],
+						"commitStatus":   sub["commitStatus"],
+					}),
+				}
+				prev := ix.Submissions[key]
+
+				items, err := devcenter.ListShippingLabels(s.ctx, s.client, token, pid, sid)
+				if err != nil {
+					unlisted = append(unlisted, fmt.Sprintf("submission %s/%s: %v", pid, sid, err))
+					if prev != nil {
+						next[key] = prev
+					}
+					continue
+				}
+				for _, it := range items {
+					if l, err := shippinglabel.DecodeLabel(it); err == nil {
+						entry.Labels = append(entry.Labels, *l)
+					}
+				}
+
+				// Versions only matter for submissions that have labels.
+				if len(entry.Labels) > 0 {
+					if prev != nil && prev.Fingerprint == entry.Fingerprint && prev.DriverVersions != nil {
+						entry.DriverVersions = prev.DriverVersions
+						reused++
+					} else if v, err := submissionDriverVersions(s, token, pid, sid); err == nil {
+						entry.DriverVersions = v
+						downloaded++
+					} else {
+						failed++
+					}
+				}
+				next[key] = entry
+			}
+			return nil
+		})
+		if err != nil {
+			unlisted = append(unlisted, fmt.Sprintf("product %s: %v", pid, err))
+			keep(func(sub *labelindex.Submission) bool { return sub.ProductID == pid })
+			productsFailed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if len(products) > 0 && productsFailed == len(products) {
+		return firstErr
+	}
+
+	ix.Submissions = next
+	ix.Updated = time.Now().UTC()
+	ui.Ok(fmt.Sprintf("Label index refreshed: %d product(s), %d submission(s); driverMetadata downloaded %d, reused %d",
+		len(products), len(next), downloaded, reused))
+	if failed > 0 {
+		ui.Warn(fmt.Sprintf("driverMetadata unavailable for %d submission(s); their driver versions show as unknown", failed))
+	}
+	if len(unlisted) > 0 {
+		ui.Warn(fmt.Sprintf("%d could not be listed and keep their previous entries; --refresh retries them:", len(unlisted)))
+		for _, u := range unlisted {
+			ui.Item(u)
+		}
+	}
+	return nil
+}
+
+// submissionDriverVersions downloads and parses a submission's
+// driverMetadata, returning its driver version per bundle/INF.
+func submissionDriverVersions(s *session, token, productID, submissionID string) (map[string]string, error) {
+	sub, err := devcenter.GetSubmission(s.ctx, s.client, token, productID, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	u, err := devcenter.FindDriverMetadataURL(sub)
+	if err != nil {
+		return nil, err
+	}
+	root, err := devcenter.DownloadDriverMetadata(s.ctx, s.client, token, u)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := drivermeta.Parse(root)
+	if err != nil {
+		return nil, err
+	}
+	return drivermeta.DriverVersions(parsed.Targets), nil
+}
@@ -0,0 +1,148 @@
+// Package chid computes Computer Hardware IDs (CHIDs) the way Microsoft's
+// ComputerHardwareIds.exe does, from SMBIOS fields.
+package chid
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Fields are the SMBIOS values CHIDs are built from, already in the text
+// form the algorithm hashes: releases as two hex digits ("01", "1f"), the
+// enclosure type as bare hex ("a").
+type Fields struct {
+	Manufacturer          string
+	Family                string
+	ProductName           string
+	SKUNumber             string
+	BIOSVendor            string
+	BIOSVersion           string
+	BIOSMajorRelease      string
+	BIOSMinorRelease      string
+	EnclosureType         string
+	BaseboardManufacturer string
+	BaseboardProduct      string
+}
+
+// Field names as printed by ComputerHardwareIds.exe.
+const (
+	keyManufacturer          = "Manufacturer"
+	keyFamily                = "Family"
+	keyProductName           = "ProductName"
+	keySKUNumber             = "SKUNumber"
+	keyBIOSVendor            = "BIOS Vendor"
+	keyBIOSVersion           = "BIOS Version"
+	keyBIOSMajorRelease      = "BIOS Major Release"
+	keyBIOSMinorRelease      = "BIOS Minor Release"
+	keyEnclosureType         = "EnclosureType"
+	keyBaseboardManufacturer = "BaseboardManufacturer"
+	keyBaseboardProduct      = "BaseboardProduct"
+)
+
+// hardwareIDKeys lists the fields of HardwareID-0 through HardwareID-14.
+var hardwareIDKeys = [][]string{
+	{keyManufacturer, keyFamily, keyProductName, keySKUNumber, keyBIOSVendor, keyBIOSVersion, keyBIOSMajorRelease, keyBIOSMinorRelease},
+	{keyManufacturer, keyFamily, keyProductName, keyBIOSVendor, keyBIOSVersion, keyBIOSMajorRelease, keyBIOSMinorRelease},
+	{keyManufacturer, keyProductName, keyBIOSVendor, keyBIOSVersion, keyBIOSMajorRelease, keyBIOSMinorRelease},
+	{keyManufacturer, keyFamily, keyProductName, keySKUNumber, keyBaseboardManufacturer, keyBaseboardProduct},
+	{keyManufacturer, keyFamily, keyProductName, keySKUNumber},
+	{keyManufacturer, keyFamily, keyProductName},
+	{keyManufacturer, keySKUNumber, keyBaseboardManufacturer, keyBaseboardProduct},
+	{keyManufacturer, keySKUNumber},
+	{keyManufacturer, keyProductName, keyBaseboardManufacturer, keyBaseboardProduct},
+	{keyManufacturer, keyProductName},
+	{keyManufacturer, keyFamily, keyBaseboardManufacturer, keyBaseboardProduct},
+	{keyManufacturer, keyFamily},
+	{keyManufacturer, keyEnclosureType},
+	{keyManufacturer, keyBaseboardManufacturer, keyBaseboardProduct},
+	{keyManufacturer},
+}
+
+func (f *Fields) value(key string) string {
+	switch key {
+	case keyManufacturer:
+		return f.Manufacturer
+	case keyFamily:
+		return f.Family
+	case keyProductName:
+		return f.ProductName
+	case keySKUNumber:
+		return f.SKUNumber
+	case keyBIOSVendor:
+		return f.BIOSVendor
+	case keyBIOSVersion:
+		return f.BIOSVersion
+	case keyBIOSMajorRelease:
+		return f.BIOSMajorRelease
+	case keyBIOSMinorRelease:
+		return f.BIOSMinorRelease
+	case keyEnclosureType:
+		return f.EnclosureType
+	case keyBaseboardManufacturer:
+		return f.BaseboardManufacturer
+	case keyBaseboardProduct:
+		return f.BaseboardProduct
+	}
+	return ""
+}
+
+// HardwareID is one computed CHID.
+type HardwareID struct {
+	Index int      `json:"index"`
+	GUID  string   `json:"chid"`
+	Keys  []string `json:"fields"`
+}
+
+// Name is "HardwareID-3" style.
+func (h HardwareID) Name() string { return fmt.Sprintf("HardwareID-%d", h.Index) }
+
+// Description is the "Manufacturer + Family + ..." text ComputerHardwareIds
+// prints after each GUID.
+func (h HardwareID) Description() string { return strings.Join(h.Keys, " + ") }
+
+// Compute returns the CHIDs whose fields are all present, in HardwareID
+// order. Values are trimmed before hashing.
+func Compute(f Fields) []HardwareID {
+	var out []HardwareID
+	for i, keys := range hardwareIDKeys {
+		values := make([]string, 0, len(keys))
+		for _, k := range keys {
+			v := strings.TrimSpace(f.value(k))
+			if v == "" {
+				values = nil
+				break
+			}
+			values = append(values, v)
+		}
+		if values == nil {
+			continue
+		}
+		out = append(out, HardwareID{Index: i, GUID: GUID(strings.Join(values, "&")), Keys: keys})
+	}
+	return out
+}
+
+// namespace is the fixed name-based UUID namespace Microsoft uses for CHIDs.
+var namespace = [16]byte{0x70, 0xff, 0xd8, 0x12, 0x4c, 0x7f, 0x4c, 0x7d}
+
+// GUID hashes s (as UTF-16LE) into a version 5 UUID in the CHID namespace.
+func GUID(s string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	for _, u := range utf16.Encode([]rune(s)) {
+		h.Write([]byte{byte(u), byte(u >> 8)})
+	}
+	b := h.Sum(nil)[:16]
+	b[6] = (b[6] & 0x0f) | 0x50 // version 5
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// FormatRelease renders a BIOS major/minor release byte ("01", "1f").
+func FormatRelease(n int) string { return fmt.Sprintf("%02x", n) }
+
+// FormatEnclosure renders an SMBIOS chassis type ("a" for Notebook); the
+// chassis lock bit is dropped.
+func FormatEnclosure(n int) string { return fmt.Sprintf("%x", n&0x7f) }
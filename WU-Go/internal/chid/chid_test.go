@@ -0,0 +1,163 @@
+package chid
+
+import "testing"
+
+func TestGUID(t *testing.T) {
+	// HardwareID-14 of any Lenovo machine (also used by fwupd's self test).
+	if got, want := GUID("LENOVO"), "6de5d951-d755-576b-bd09-c5cf66b27234"; got != want {
+		t.Fatalf("GUID(LENOVO) = %s, want %s", got, want)
+	}
+}
+
+func TestComputeSkipsMissingFields(t *testing.T) {
+	ids := Compute(Fields{Manufacturer: " LENOVO ", EnclosureType: "a"})
+	if len(ids) != 2 {
+		t.Fatalf("got %d ids, want 2 (HardwareID-12, -14): %+v", len(ids), ids)
+	}
+	if ids[0].Index != 12 || ids[1].Index != 14 {
+		t.Fatalf("indexes = %d, %d", ids[0].Index, ids[1].Index)
+	}
+	if ids[1].GUID != GUID("LENOVO") {
+		t.Fatalf("HardwareID-14 not trimmed: %s", ids[1].GUID)
+	}
+	if ids[0].Description() != "Manufacturer + EnclosureType" {
+		t.Fatalf("description = %q", ids[0].Description())
+	}
+}
+
+func TestComputeAll(t *testing.T) {
+	f := Fields{
+		Manufacturer: "Contoso", Family: "Laptop", ProductName: "X1", SKUNumber: "SKU1",
+		BIOSVendor: "Contoso", BIOSVersion: "1.0", BIOSMajorRelease: "01", BIOSMinorRelease: "1f",
+		EnclosureType: "a", BaseboardManufacturer: "Contoso", BaseboardProduct: "BB1",
+	}
+	ids := Compute(f)
+	if len(ids) != 15 {
+		t.Fatalf("got %d ids, want 15", len(ids))
+	}
+	if ids[0].GUID != GUID("Contoso&Laptop&X1&SKU1&Contoso&1.0&01&1f") {
+		t.Fatalf("HardwareID-0 = %s", ids[0].GUID)
+	}
+}
+
+// smbiosStruct builds one SMBIOS structure from its formatted area (header
+// included) and strings.
+func smbiosStruct(formatted []byte, strs ...string) []byte {
+	b := append([]byte{}, formatted...)
+	for _, s := range strs {
+		b = append(b, s...)
+		b = append(b, 0)
+	}
+	if len(strs) == 0 {
+		b = append(b, 0)
+	}
+	return append(b, 0)
+}
+
+func TestParseSMBIOS(t *testing.T) {
+	bios := make([]byte, 0x18)
+	bios[0], bios[1] = typeBIOS, 0x18
+	bios[0x04], bios[0x05] = 1, 2
+	bios[0x14], bios[0x15] = 1, 31
+
+	system := make([]byte, 0x1b)
+	system[0], system[1] = typeSystem, 0x1b
+	system[0x04], system[0x05], system[0x19], system[0x1a] = 1, 2, 3, 4
+
+	board := make([]byte, 0x08)
+	board[0], board[1] = typeBaseboard, 0x08
+	board[0x04], board[0x05] = 1, 2
+
+	chassis := make([]byte, 0x09)
+	chassis[0], chassis[1] = typeChassis, 0x09
+	chassis[0x04], chassis[0x05] = 1, 0x80|10
+
+	end := []byte{typeEnd, 4, 0, 0}
+
+	var table []byte
+	table = append(table, smbiosStruct(bios, "Contoso", "1.0")...)
+	table = append(table, smbiosStruct(system, "Contoso", "X1", "SKU1", "Laptop")...)
+	table = append(table, smbiosStruct(board, "Contoso", "BB1")...)
+	table = append(table, smbiosStruct(chassis, "Contoso")...)
+	table = append(table, smbiosStruct(end)...)
+
+	want := Fields{
+		Manufacturer: "Contoso", Family: "Laptop", ProductName: "X1", SKUNumber: "SKU1",
+		BIOSVendor: "Contoso", BIOSVersion: "1.0", BIOSMajorRelease: "01", BIOSMinorRelease: "1f",
+		EnclosureType: "a", BaseboardManufacturer: "Contoso", BaseboardProduct: "BB1",
+	}
+	got, err := ParseSMBIOS(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	// Windows RSMB dump: 8-byte header with the table length.
+	n := len(table)
+	rsmb := append([]byte{0, 3, 4, 0, byte(n), byte(n >> 8), 0, 0}, table...)
+	if got, err := ParseSMBIOS(rsmb); err != nil || got != want {
+		t.Fatalf("RSMB: got %+v, %v", got, err)
+	}
+
+	if _, err := ParseSMBIOS(smbiosStruct(end)); err == nil {
+		t.Fatal("expected error for a table without type 0/1")
+	}
+}
+
+func TestParseDmidecode(t *testing.T) {
+	text := `# dmidecode 3.3
+SMBIOS 3.2.0 present.
+
+Handle 0x0000, DMI type 0, 26 bytes
+BIOS Information
+	Vendor: Contoso
+	Version: 1.0
+	Characteristics:
+		PCI is supported
+	BIOS Revision: 1.31
+
+Handle 0x0001, DMI type 1, 27 bytes
+System Information
+	Manufacturer: Contoso
+	Product Name: X1
+	Version: ThinkPad
+	SKU Number: SKU1
+	Family: Laptop
+
+Handle 0x0002, DMI type 2, 15 bytes
+Base Board Information
+	Manufacturer: Contoso
+	Product Name: BB1
+
+Handle 0x0003, DMI type 3, 34 bytes
+Chassis Information
+	Manufacturer: Other
+	Type: Notebook
+	Lock: Not Present
+`
+	got, err := ParseDmidecode(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Fields{
+		Manufacturer: "Contoso", Family: "Laptop", ProductName: "X1", SKUNumber: "SKU1",
+		BIOSVendor: "Contoso", BIOSVersion: "1.0", BIOSMajorRelease: "01", BIOSMinorRelease: "1f",
+		EnclosureType: "a", BaseboardManufacturer: "Contoso", BaseboardProduct: "BB1",
+	}
+	if got != want {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestChassisType(t *testing.T) {
+	for in, want := range map[string]int{"Notebook": 10, "convertible": 31, "9": 9} {
+		if n, ok := ChassisType(in); !ok || n != want {
+			t.Errorf("ChassisType(%q) = %d, %v", in, n, ok)
+		}
+	}
+	if _, ok := ChassisType("Spaceship"); ok {
+		t.Error("unknown chassis type accepted")
+	}
+}
@@ -0,0 +1,235 @@
+package chid
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"WU/internal/format"
+	"WU/internal/support"
+)
+
+// Registry maps machine/project names to their CHIDs. It is kept as a JSON
+// file so it can be shared and reviewed like any other config.
+type Registry struct {
+	Projects []Project `json:"projects"`
+}
+
+// Project is one machine or project and the CHIDs it reports.
+type Project struct {
+	Name  string  `json:"name"`
+	CHIDs []Entry `json:"chids"`
+}
+
+// Entry is a registered CHID with the fields it was computed from, when
+// known ("Manufacturer + Family + ProductName").
+type Entry struct {
+	CHID   string `json:"chid"`
+	Fields string `json:"fields,omitempty"`
+}
+
+var guidRegex = regexp.MustCompile(`\{?([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})\}?`)
+
+// Normalize returns the lowercase CHID without braces, or "" when s is not
+// a GUID.
+func Normalize(s string) string {
+	s = strings.TrimSpace(s)
+	m := guidRegex.FindStringSubmatch(s)
+	if m == nil || len(m[0]) != len(s) {
+		return ""
+	}
+	return strings.ToLower(m[1])
+}
+
+// LoadRegistry reads the registry at path; a missing file is an empty
+// registry.
+func LoadRegistry(path string) (*Registry, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Registry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Registry
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, support.NewAPIError("CHID 注册表无法解析: " + path + ": " + err.Error())
+	}
+	return &r, nil
+}
+
+// Save writes the registry to path, projects sorted by name.
+func (r *Registry) Save(path string) error {
+	sort.SliceStable(r.Projects, func(i, j int) bool {
+		return strings.ToLower(r.Projects[i].Name) < strings.ToLower(r.Projects[j].Name)
+	})
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, format.MustJSONIndent(r), 0644)
+}
+
+// Project returns the project with this name (case-insensitive), or nil.
+func (r *Registry) Project(name string) *Project {
+	for i := range r.Projects {
+		if strings.EqualFold(r.Projects[i].Name, strings.TrimSpace(name)) {
+			return &r.Projects[i]
+		}
+	}
+	return nil
+}
+
+// Add merges entries into the named project, creating it if needed. It
+// returns how many CHIDs were new to the project.
+func (r *Registry) Add(name string, entries []Entry) int {
+	p := r.Project(name)
+	if p == nil {
+		r.Projects = append(r.Projects, Project{Name: strings.TrimSpace(name)})
+		p = &r.Projects[len(r.Projects)-1]
+	}
+	added := 0
+	for _, e := range entries {
+		e.CHID = Normalize(e.CHID)
+		if e.CHID == "" {
+			continue
+		}
+		if i := p.index(e.CHID); i >= 0 {
+			if p.CHIDs[i].Fields == "" {
+				p.CHIDs[i].Fields = e.Fields
+			}
+			continue
+		}
+		p.CHIDs = append(p.CHIDs, e)
+		added++
+	}
+	return added
+}
+
+func (p *Project) index(chid string) int {
+	for i, e := range p.CHIDs {
+		if e.CHID == chid {
+			return i
+		}
+	}
+	return -1
+}
+
+// GUIDs returns the project's CHIDs.
+func (p *Project) GUIDs() []string {
+	out := make([]string, 0, len(p.CHIDs))
+	for _, e := range p.CHIDs {
+		out = append(out, e.CHID)
+	}
+	return out
+}
+
+// Owners returns the names of the projects that list chid.
+func (r *Registry) Owners(chid string) []string {
+	chid = Normalize(chid)
+	var out []string
+	for i := range r.Projects {
+		if r.Projects[i].index(chid) >= 0 {
+			out = append(out, r.Projects[i].Name)
+		}
+	}
+	return out
+}
+
+// Find returns the projects whose name contains query (case-insensitive),
+// or that list it when query is a CHID.
+func (r *Registry) Find(query string) []Project {
+	var out []Project
+	if c := Normalize(query); c != "" {
+		for _, p := range r.Projects {
+			if p.index(c) >= 0 {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+	q := strings.ToLower(strings.TrimSpace(query))
+	for _, p := range r.Projects {
+		if support.ContainsLower(p.Name, q) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ParseHardwareIDsText extracts the CHIDs from ComputerHardwareIds.exe
+// output ("{guid}   <- Manufacturer + Family"), keeping the field list.
+func ParseHardwareIDsText(text string) []Entry {
+	var out []Entry
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := sc.Text()
+		m := guidRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		c := strings.ToLower(m[1])
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		fields := ""
+		if _, rest, ok := strings.Cut(line, "<-"); ok {
+			fields = strings.TrimSpace(strings.TrimLeft(rest, "-"))
+		}
+		out = append(out, Entry{CHID: c, Fields: fields})
+	}
+	return out
+}
+
+// ParseCSV reads "project,chid[,fields]" rows into project names and
+// entries, in file order. A header row is skipped.
+func ParseCSV(r io.Reader) ([]string, map[string][]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var names []string
+	byName := map[string][]Entry{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, support.NewAPIError("CSV 无法解析: " + err.Error())
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		if len(rec) < 2 {
+			return nil, nil, support.NewAPIError(fmt.Sprintf("CSV 第 %d 行需要 project,chid 两列", line))
+		}
+		name, c := strings.TrimSpace(rec[0]), Normalize(rec[1])
+		if c == "" {
+			if line == 1 {
+				continue // header
+			}
+			return nil, nil, support.NewAPIError(fmt.Sprintf("CSV 第 %d 行 CHID 不是合法 GUID: %s", line, rec[1]))
+		}
+		if name == "" {
+			return nil, nil, support.NewAPIError(fmt.Sprintf("CSV 第 %d 行缺少 project 名称", line))
+		}
+		e := Entry{CHID: c}
+		if len(rec) > 2 {
+			e.Fields = strings.TrimSpace(rec[2])
+		}
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], e)
+	}
+	return names, byName, nil
+}
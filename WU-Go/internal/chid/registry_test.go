@@ -0,0 +1,95 @@
+package chid
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const hwidsOutput = `Computer Information
+--------------------
+BIOS Vendor: LENOVO
+Manufacturer: LENOVO
+
+Hardware IDs
+------------
+{6DE5D951-D755-576B-BD09-C5CF66B27234}   <- Manufacturer
+{e093d715-70f7-51f4-b6c8-b4a7e31def85}   <-- Manufacturer + EnclosureType
+{6de5d951-d755-576b-bd09-c5cf66b27234}   <- Manufacturer
+`
+
+func TestParseHardwareIDsText(t *testing.T) {
+	got := ParseHardwareIDsText(hwidsOutput)
+	want := []Entry{
+		{CHID: "6de5d951-d755-576b-bd09-c5cf66b27234", Fields: "Manufacturer"},
+		{CHID: "e093d715-70f7-51f4-b6c8-b4a7e31def85", Fields: "Manufacturer + EnclosureType"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	in := "project,chid,fields\n" +
+		"X1 Carbon, {6DE5D951-D755-576B-BD09-C5CF66B27234},Manufacturer\n" +
+		"T14,e093d715-70f7-51f4-b6c8-b4a7e31def85\n" +
+		"X1 Carbon,e093d715-70f7-51f4-b6c8-b4a7e31def85\n"
+	names, byName, err := ParseCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, "|") != "X1 Carbon|T14" {
+		t.Fatalf("names = %v", names)
+	}
+	if len(byName["X1 Carbon"]) != 2 || byName["X1 Carbon"][0].Fields != "Manufacturer" {
+		t.Fatalf("X1 Carbon = %+v", byName["X1 Carbon"])
+	}
+
+	if _, _, err := ParseCSV(strings.NewReader("a,b\nX1,not-a-guid\n")); err == nil {
+		t.Fatal("expected error for a bad CHID")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chids.json")
+	r, err := LoadRegistry(path)
+	if err != nil || len(r.Projects) != 0 {
+		t.Fatalf("missing registry: %+v, %v", r, err)
+	}
+
+	if n := r.Add("X1 Carbon", ParseHardwareIDsText(hwidsOutput)); n != 2 {
+		t.Fatalf("added %d, want 2", n)
+	}
+	if n := r.Add("x1 carbon", []Entry{{CHID: "{6DE5D951-D755-576B-BD09-C5CF66B27234}"}}); n != 0 {
+		t.Fatalf("duplicate added: %d", n)
+	}
+	r.Add("T14", []Entry{{CHID: "e093d715-70f7-51f4-b6c8-b4a7e31def85"}})
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = LoadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Projects[0].Name != "T14" {
+		t.Fatalf("not sorted: %+v", r.Projects)
+	}
+	if got := r.Owners("E093D715-70F7-51F4-B6C8-B4A7E31DEF85"); strings.Join(got, "|") != "T14|X1 Carbon" {
+		t.Fatalf("owners = %v", got)
+	}
+	if got := r.Find("carbon"); len(got) != 1 || len(got[0].CHIDs) != 2 {
+		t.Fatalf("find by name = %+v", got)
+	}
+	if got := r.Find("6de5d951-d755-576b-bd09-c5cf66b27234"); len(got) != 1 {
+		t.Fatalf("find by chid = %+v", got)
+	}
+	if r.Owners("00000000-0000-0000-0000-000000000000") != nil {
+		t.Fatal("unknown CHID has owners")
+	}
+}
@@ -0,0 +1,206 @@
+package chid
+
+import (
+	"bufio"
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"WU/internal/support"
+)
+
+// DefaultSMBIOSPath is where Linux exposes the raw SMBIOS table.
+const DefaultSMBIOSPath = "/sys/firmware/dmi/tables/DMI"
+
+// SMBIOS structure types used for CHIDs.
+const (
+	typeBIOS      = 0
+	typeSystem    = 1
+	typeBaseboard = 2
+	typeChassis   = 3
+	typeEnd       = 127
+)
+
+// ParseSMBIOS reads a raw SMBIOS structure table, as found in
+// /sys/firmware/dmi/tables/DMI or returned by Windows
+// GetSystemFirmwareTable('RSMB') (whose 8-byte header is skipped).
+func ParseSMBIOS(b []byte) (Fields, error) {
+	var f Fields
+	if len(b) >= 8 && b[0] <= 1 && int(binary.LittleEndian.Uint32(b[4:8])) == len(b)-8 {
+		b = b[8:]
+	}
+
+	found := false
+	for len(b) >= 4 {
+		typ, length := b[0], int(b[1])
+		if length < 4 || length > len(b) {
+			return f, support.NewAPIError("SMBIOS 表结构长度无效")
+		}
+		formatted := b[:length]
+
+		// Strings follow the formatted area and end with a double NUL.
+		end := length
+		for end+1 < len(b) && !(b[end] == 0 && b[end+1] == 0) {
+			end++
+		}
+		var strs []string
+		if end > length {
+			strs = strings.Split(string(b[length:end]), "\x00")
+		}
+		str := func(off int) string {
+			if off >= len(formatted) {
+				return ""
+			}
+			i := int(formatted[off])
+			if i == 0 || i > len(strs) {
+				return ""
+			}
+			return strings.TrimSpace(strs[i-1])
+		}
+		byteAt := func(off int) (int, bool) {
+			if off >= len(formatted) {
+				return 0, false
+			}
+			return int(formatted[off]), true
+		}
+
+		switch typ {
+		case typeBIOS:
+			found = true
+			f.BIOSVendor = str(0x04)
+			f.BIOSVersion = str(0x05)
+			if v, ok := byteAt(0x14); ok {
+				f.BIOSMajorRelease = FormatRelease(v)
+			}
+			if v, ok := byteAt(0x15); ok {
+				f.BIOSMinorRelease = FormatRelease(v)
+			}
+		case typeSystem:
+			found = true
+			f.Manufacturer = str(0x04)
+			f.ProductName = str(0x05)
+			f.SKUNumber = str(0x19)
+			f.Family = str(0x1a)
+		case typeBaseboard:
+			if f.BaseboardManufacturer == "" {
+				f.BaseboardManufacturer = str(0x04)
+				f.BaseboardProduct = str(0x05)
+			}
+		case typeChassis:
+			if v, ok := byteAt(0x05); ok && f.EnclosureType == "" {
+				f.EnclosureType = FormatEnclosure(v)
+			}
+		}
+
+		if end+2 > len(b) || typ == typeEnd {
+			break
+		}
+		b = b[end+2:]
+	}
+	if !found {
+		return f, support.NewAPIError("SMBIOS 表中没有 BIOS/System 信息（type 0/1）")
+	}
+	return f, nil
+}
+
+// ParseDmidecode reads the text output of `dmidecode` (all types, or at
+// least -t 0,1,2,3).
+func ParseDmidecode(text string) (Fields, error) {
+	var f Fields
+	section := -1
+	found := false
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "Handle ") {
+			section = -1
+			if i := strings.Index(line, "DMI type "); i >= 0 {
+				rest := line[i+len("DMI type "):]
+				if j := strings.IndexByte(rest, ','); j >= 0 {
+					section, _ = strconv.Atoi(rest[:j])
+				}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || !strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch section {
+		case typeBIOS:
+			found = true
+			switch key {
+			case "Vendor":
+				f.BIOSVendor = value
+			case "Version":
+				f.BIOSVersion = value
+			case "BIOS Revision":
+				major, minor, _ := strings.Cut(value, ".")
+				if n, err := strconv.Atoi(major); err == nil {
+					f.BIOSMajorRelease = FormatRelease(n)
+				}
+				if n, err := strconv.Atoi(minor); err == nil {
+					f.BIOSMinorRelease = FormatRelease(n)
+				}
+			}
+		case typeSystem:
+			found = true
+			switch key {
+			case "Manufacturer":
+				f.Manufacturer = value
+			case "Product Name":
+				f.ProductName = value
+			case "SKU Number":
+				f.SKUNumber = value
+			case "Family":
+				f.Family = value
+			}
+		case typeBaseboard:
+			switch key {
+			case "Manufacturer":
+				f.BaseboardManufacturer = support.FirstNonEmpty(f.BaseboardManufacturer, value)
+			case "Product Name":
+				f.BaseboardProduct = support.FirstNonEmpty(f.BaseboardProduct, value)
+			}
+		case typeChassis:
+			if key == "Type" && f.EnclosureType == "" {
+				if n, ok := ChassisType(value); ok {
+					f.EnclosureType = FormatEnclosure(n)
+				}
+			}
+		}
+	}
+	if !found {
+		return f, support.NewAPIError("dmidecode 输出中没有 BIOS/System Information（DMI type 0/1）")
+	}
+	return f, nil
+}
+
+// chassisTypes are the SMBIOS chassis type names dmidecode prints.
+var chassisTypes = []string{
+	1: "Other", "Unknown", "Desktop", "Low Profile Desktop", "Pizza Box",
+	"Mini Tower", "Tower", "Portable", "Laptop", "Notebook", "Hand Held",
+	"Docking Station", "All In One", "Sub Notebook", "Space-saving",
+	"Lunch Box", "Main Server Chassis", "Expansion Chassis", "Sub Chassis",
+	"Bus Expansion Chassis", "Peripheral Chassis", "RAID Chassis",
+	"Rack Mount Chassis", "Sealed-case PC", "Multi-system", "CompactPCI",
+	"AdvancedTCA", "Blade", "Blade Enclosing", "Tablet", "Convertible",
+	"Detachable", "IoT Gateway", "Embedded PC", "Mini PC", "Stick PC",
+}
+
+// ChassisType maps a chassis type name ("Notebook") or number ("10") to
+// its SMBIOS code.
+func ChassisType(s string) (int, bool) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	for i, name := range chassisTypes {
+		if name != "" && strings.EqualFold(name, s) {
+			return i, true
+		}
+	}
+	return 0, false
+}
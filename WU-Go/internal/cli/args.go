@@ -5,6 +5,7 @@ import "strings"
 type ArgSet struct {
 	values map[string][]string
 	flags  map[string]bool
+	args   []string
 }
 
 func ParseArgs(argv []string) *ArgSet {
@@ -15,13 +16,15 @@ func ParseArgs(argv []string) *ArgSet {
 
 	isFlag := func(a string) bool {
 		switch a {
-		case "--select-all", "--dry-run", "--schedule-go-live",
+		case "--select-all", "--dry-run", "--allow-conflicts", "--schedule-go-live",
 			"--auto-install-os-upgrade", "--no-auto-install-os-upgrade",
 			"--auto-install-applicable", "--no-auto-install-applicable",
 			"--is-disclosure-restricted", "--publish-to-windows10s",
 			"--is-reboot-required", "--is-co-engineered",
 			"--is-for-unreleased-hardware", "--has-ui-software",
-			"--no-ui", "--no-filter":
+			"--no-ui", "--no-filter", "--group-by-os",
+			"--verbose", "--enforce-chid-targeting", "--yes",
+			"--no-history", "--resume", "--refresh", "--offline":
 			return true
 		default:
 			return false
@@ -31,11 +34,22 @@ func ParseArgs(argv []string) *ArgSet {
 	addValue := func(k, v string) { m.values[k] = append(m.values[k], v) }
 	addFlag := func(k string) { m.flags[k] = true }
 
+	// Positional arguments (the subcommand and its operands) come before
+	// the first option; a bare token after an option is ignored, as it is
+	// more likely a stray word of an unquoted value than a command.
+	positional := true
 	for i := 0; i < len(argv); i++ {
 		a := argv[i]
+		if a == "-f" {
+			a = "--file"
+		}
 		if !strings.HasPrefix(a, "--") {
+			if positional {
+				m.args = append(m.args, a)
+			}
 			continue
 		}
+		positional = false
 		if isFlag(a) {
 			addFlag(a)
 			continue
@@ -45,7 +59,7 @@ func ParseArgs(argv []string) *ArgSet {
 			continue
 		}
 
-		if a == "--visible-to-accounts" || a == "--affected-oems" || a == "--chids" {
+		if a == "--visible-to-accounts" || a == "--affected-oems" || a == "--chids" || a == "--audience" {
 			for i+1 < len(argv) && !strings.HasPrefix(argv[i+1], "--") {
 				i++
 				addValue(a, argv[i])
@@ -74,3 +88,7 @@ func (m *ArgSet) GetMany(key string) []string {
 	}
 	return []string{}
 }
+
+// Args returns the positional arguments given before the first option, e.g.
+// the subcommand name and its operands.
+func (m *ArgSet) Args() []string { return append([]string{}, m.args...) }
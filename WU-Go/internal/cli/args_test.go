@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseArgsPositionalBeforeOptions(t *testing.T) {
+	for _, tc := range []struct {
+		argv []string
+		want []string
+	}{
+		{[]string{"metadata", "diff", "a.json", "b.json", "--format", "json"}, []string{"metadata", "diff", "a.json", "b.json"}},
+		{[]string{"help", "label"}, []string{"help", "label"}},
+		// an unquoted value leaves stray words, which must not become a
+		// subcommand
+		{[]string{"--name", "My", "label", "--dry-run"}, []string{}},
+		{[]string{"--dry-run", "whereis"}, []string{}},
+		{[]string{"-f", "labels.yaml", "apply"}, []string{}},
+	} {
+		m := ParseArgs(tc.argv)
+		if got := m.Args(); !slices.Equal(got, tc.want) {
+			t.Errorf("ParseArgs(%q).Args() = %q, want %q", tc.argv, got, tc.want)
+		}
+	}
+	if m := ParseArgs([]string{"label", "post", "--name", "My", "label"}); m.GetSingle("--name") != "My" {
+		t.Errorf("--name = %q, want My", m.GetSingle("--name"))
+	}
+}
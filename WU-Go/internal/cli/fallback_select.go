@@ -1,27 +1,29 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+
+	"WU/internal/support"
+	"WU/internal/terminal"
 )
 
 func PromptIndexSelection(title string, items []string, allowEmpty bool, multi bool) ([]int, error) {
-	fmt.Println("\n" + strings.Repeat("=", 100))
-	fmt.Println(title)
-	fmt.Println(strings.Repeat("-", 100))
+	t := terminal.Current()
+	fmt.Fprintln(t, "\n"+strings.Repeat("=", 100))
+	fmt.Fprintln(t, title)
+	fmt.Fprintln(t, strings.Repeat("-", 100))
 	if len(items) == 0 {
-		fmt.Println("(无可选项)")
+		fmt.Fprintln(t, "(无可选项)")
 		return []int{}, nil
 	}
 	for i, it := range items {
-		fmt.Printf("[%5d] %s\n", i+1, it)
+		fmt.Fprintf(t, "[%5d] %s\n", i+1, it)
 	}
-	fmt.Println(strings.Repeat("-", 100))
+	fmt.Fprintln(t, strings.Repeat("-", 100))
 
 	hint := "输入序号"
 	if multi {
@@ -31,10 +33,13 @@ func PromptIndexSelection(title string, items []string, allowEmpty bool, multi b
 		hint += "；回车=不选"
 	}
 
-	in := bufio.NewReader(os.Stdin)
 	for {
-		fmt.Print(hint + ": ")
-		expr, _ := in.ReadString('\n')
+		fmt.Fprint(t, hint+": ")
+		expr, readErr := terminal.ReadLine(t)
+		if readErr != nil && expr == "" {
+			// Input ended (closed stdin); asking again would loop forever.
+			return nil, support.ErrCanceled
+		}
 		expr = strings.TrimSpace(expr)
 		if allowEmpty && expr == "" {
 			return []int{}, nil
@@ -53,7 +58,7 @@ func PromptIndexSelection(title string, items []string, allowEmpty bool, multi b
 			}
 		}
 		if err != nil {
-			fmt.Println("输入有误：" + err.Error())
+			fmt.Fprintln(t, "输入有误："+err.Error())
 			continue
 		}
 
@@ -65,11 +70,11 @@ func PromptIndexSelection(title string, items []string, allowEmpty bool, multi b
 			}
 		}
 		if !ok {
-			fmt.Println("序号超范围。")
+			fmt.Fprintln(t, "序号超范围。")
 			continue
 		}
 		if !allowEmpty && len(idxs) == 0 {
-			fmt.Println("至少选择一个。")
+			fmt.Fprintln(t, "至少选择一个。")
 			continue
 		}
 
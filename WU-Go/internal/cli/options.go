@@ -3,27 +3,49 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"WU/internal/support"
 )
 
 type CLIOptions struct {
+	// Args holds positional arguments: the subcommand and its operands.
+	Args []string
+
 	TenantID     string
 	ClientID     string
 	ClientSecret string
 	ProductID    string
 	SubmissionID string
 
-	SelectAll bool
-	DryRun    bool
+	SelectAll      bool
+	DryRun         bool
+	AllowConflicts bool
 	OutPath   string
 
 	Destination string
 	Name        string
 
+	// Splitting one selection into several labels
+	SplitBy      string // count | bundle | os
+	MaxTargets   int
+	NameTemplate string
+
+	// anotherPartner destination
+	ReceiverPublisherID  string
+	EnforceChidTargeting bool
+	// TargetingNotes explain the targeting to the partner. The shipping
+	// label API has no field for them, so they are kept in the run history
+	// and ledger only.
+	TargetingNotes       string
+
 	GoLiveImmediate bool
 	GoLiveDate      string
 
+	// inServicePublishInfo release range (e.g. RS5 / NI)
+	FloorOS   string
+	CeilingOS string
+
 	VisibleToAccounts []int
 
 	AutoInstallDuringOSUpgrade     bool
@@ -41,10 +63,62 @@ type CLIOptions struct {
 	HasUiSoftware         bool
 	BusinessJustification string
 
-	Chids []string
+	Chids     []string
+	CHIDsFile string // GUID list or ComputerHardwareIds.exe output
+
+	// restrictedToAudiences (audience IDs or names)
+	Audiences []string
 
 	NoUI       bool
 	OfferFilter bool
+
+	OSFilter  string
+	GroupByOS bool
+
+	Format  string
+	Verbose bool
+
+	MetadataFile string
+	From         string
+
+	// Run archive and ledger (wu history)
+	HistoryDir string
+	NoHistory  bool
+	Resume     bool
+	CHID       string
+	PnP        string
+
+	// wu whereis
+	HWID    string
+	Refresh bool
+	Offline bool
+
+	// wu plan / wu apply
+	SpecFile string
+	Yes      bool
+
+	// wu chid compute: SMBIOS source, or explicit fields
+	SMBIOSFile    string
+	DmidecodeFile string
+	SMBIOS        SMBIOSFields
+
+	// CHID registry (wu chid add/list/find and the Step 4 picker)
+	CHIDRegistry string
+	Import       string
+}
+
+// SMBIOSFields are explicit SMBIOS values given on the command line.
+type SMBIOSFields struct {
+	Manufacturer          string
+	Family                string
+	ProductName           string
+	SKUNumber             string
+	BaseboardManufacturer string
+	BaseboardProduct      string
+	BIOSVendor            string
+	BIOSVersion           string
+	BIOSRelease           string // "major.minor", e.g. 1.31
+	EnclosureType         string // chassis type number or name
 }
 
 func defaultCLIOptions() *CLIOptions {
@@ -74,6 +148,8 @@ func ParseCLIOptions(argv []string) (*CLIOptions, error) {
 	o := defaultCLIOptions()
 	m := ParseArgs(argv)
 
+	o.Args = m.Args()
+
 	o.TenantID = m.GetSingle("--tenant-id")
 	o.ClientID = m.GetSingle("--client-id")
 	o.ClientSecret = m.GetSingle("--client-secret")
@@ -82,10 +158,24 @@ func ParseCLIOptions(argv []string) (*CLIOptions, error) {
 
 	o.SelectAll = m.HasFlag("--select-all")
 	o.DryRun = m.HasFlag("--dry-run")
-	o.OutPath = support.FirstNonEmpty(m.GetSingle("--out"), "shippinglabel.request.json")
+	o.AllowConflicts = m.HasFlag("--allow-conflicts")
+	o.HistoryDir = m.GetSingle("--history-dir")
+	o.NoHistory = m.HasFlag("--no-history")
+	o.Resume = m.HasFlag("--resume")
+	o.CHID = m.GetSingle("--chid")
+	o.PnP = m.GetSingle("--pnp")
+	o.HWID = m.GetSingle("--hwid")
+	o.Refresh = m.HasFlag("--refresh")
+	o.Offline = m.HasFlag("--offline")
+	o.SpecFile = m.GetSingle("--file")
+	o.Yes = m.HasFlag("--yes")
+	o.OutPath = m.GetSingle("--out") // default applied by the command that writes it
 
 	o.Destination = support.FirstNonEmpty(m.GetSingle("--destination"), o.Destination)
 	o.Name = m.GetSingle("--name")
+	o.ReceiverPublisherID = m.GetSingle("--receiver-publisher-id")
+	o.EnforceChidTargeting = m.HasFlag("--enforce-chid-targeting")
+	o.TargetingNotes = strings.TrimSpace(m.GetSingle("--targeting-notes"))
 
 	o.GoLiveImmediate = !m.HasFlag("--schedule-go-live")
 	if v := m.GetSingle("--go-live-date"); !support.IsBlank(v) {
@@ -93,6 +183,19 @@ func ParseCLIOptions(argv []string) (*CLIOptions, error) {
 		o.GoLiveImmediate = false
 	}
 
+	o.SplitBy = strings.ToLower(strings.TrimSpace(m.GetSingle("--split-by")))
+	o.NameTemplate = m.GetSingle("--name-template")
+	if v := m.GetSingle("--max-targets"); !support.IsBlank(v) {
+		n, err := support.ParseIntStrict(v)
+		if err != nil || n <= 0 {
+			return nil, support.NewAPIError("--max-targets 需要正整数，但输入为: " + v)
+		}
+		o.MaxTargets = n
+	}
+
+	o.FloorOS = m.GetSingle("--floor-os")
+	o.CeilingOS = m.GetSingle("--ceiling-os")
+
 	for _, s := range m.GetMany("--visible-to-accounts") {
 		n, err := support.ParseIntStrict(s)
 		if err != nil {
@@ -138,12 +241,40 @@ func ParseCLIOptions(argv []string) (*CLIOptions, error) {
 	}
 
 	o.Chids = append([]string{}, m.GetMany("--chids")...)
+	o.CHIDsFile = m.GetSingle("--chids-file")
+	o.Audiences = append([]string{}, m.GetMany("--audience")...)
 
 	o.NoUI = m.HasFlag("--no-ui")
 	if m.HasFlag("--no-filter") {
 		o.OfferFilter = false
 	}
 
+	o.OSFilter = m.GetSingle("--os-filter")
+	o.GroupByOS = m.HasFlag("--group-by-os")
+
+	o.Format = m.GetSingle("--format")
+	o.Verbose = m.HasFlag("--verbose")
+
+	o.MetadataFile = m.GetSingle("--metadata-file")
+	o.From = m.GetSingle("--from")
+
+	o.CHIDRegistry = m.GetSingle("--chid-registry")
+	o.Import = m.GetSingle("--import")
+	o.SMBIOSFile = m.GetSingle("--smbios")
+	o.DmidecodeFile = m.GetSingle("--dmidecode")
+	o.SMBIOS = SMBIOSFields{
+		Manufacturer:          m.GetSingle("--manufacturer"),
+		Family:                m.GetSingle("--family"),
+		ProductName:           m.GetSingle("--product-name"),
+		SKUNumber:             m.GetSingle("--sku"),
+		BaseboardManufacturer: m.GetSingle("--baseboard-manufacturer"),
+		BaseboardProduct:      m.GetSingle("--baseboard-product"),
+		BIOSVendor:            m.GetSingle("--bios-vendor"),
+		BIOSVersion:           m.GetSingle("--bios-version"),
+		BIOSRelease:           m.GetSingle("--bios-release"),
+		EnclosureType:         m.GetSingle("--enclosure-type"),
+	}
+
 	return o, nil
 }
 
@@ -0,0 +1,58 @@
+package devcenter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pagedLabels serves three labels of submission 1/2, two per page.
+func pagedLabels(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/1/submissions/2/shippingLabels" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("skip") {
+		case "":
+			fmt.Fprint(w, `{"value": [{"id": 1152921504606846977}, {"id": 2}],
+				"links": [{"rel": "next_link", "href": "products/1/submissions/2/shippingLabels?skip=2"}]}`)
+		case "2":
+			fmt.Fprint(w, `{"value": [{"id": 3}], "links": []}`)
+		default:
+			http.Error(w, "bad skip", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestListShippingLabelsFollowsNextLink(t *testing.T) {
+	srv := pagedLabels(t)
+	labels, err := ListShippingLabels(context.Background(), NewClient(srv.URL), "token", "1", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, l := range labels {
+		ids = append(ids, string(l["id"].(json.Number)))
+	}
+	if got := strings.Join(ids, ","); got != "1152921504606846977,2,3" {
+		t.Errorf("ids = %s", got)
+	}
+}
+
+func TestListPagesStopsEndlessPaging(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": [], "links": [{"rel": "next_link", "href": "products/1/submissions"}]}`)
+	}))
+	defer srv.Close()
+	if _, err := ListSubmissions(context.Background(), NewClient(srv.URL), "token", "1"); err == nil || !strings.Contains(err.Error(), "分页次数过多") {
+		t.Errorf("ListSubmissions() error = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package devcenter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"WU/internal/support"
+)
+
+// ListProducts returns all hardware products of the account (GET /products).
+func ListProducts(ctx context.Context, c *Client, token string) ([]map[string]any, error) {
+	return listPages(ctx, c, token, c.BaseAPI+"/products", "products")
+}
+
+// listPages GETs a collection and returns the "value" arrays of all its
+// pages, following the "next_link" of each page. Numbers are kept as
+// json.Number, since label and submission IDs exceed float64 precision.
+// what names the collection in errors.
+func listPages(ctx context.Context, c *Client, token, u, what string) ([]map[string]any, error) {
+	base, err := url.Parse(c.BaseAPI + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var out []map[string]any
+	for page := 0; u != ""; page++ {
+		if page >= 1000 {
+			return nil, support.NewAPIError("GET " + what + " 分页次数过多")
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, support.NewAPIError(fmt.Sprintf("GET %s 失败: %d\n%s", what, resp.StatusCode, string(body)))
+		}
+
+		var obj struct {
+			Value []map[string]any `json:"value"`
+			Links []struct {
+				Href string `json:"href"`
+				Rel  string `json:"rel"`
+			} `json:"links"`
+		}
+		dec := json.NewDecoder(strings.NewReader(string(body)))
+		dec.UseNumber()
+		if err := dec.Decode(&obj); err != nil {
+			return nil, support.NewAPIError(what + " 响应不是合法 JSON: " + err.Error())
+		}
+		out = append(out, obj.Value...)
+
+		u = ""
+		for _, l := range obj.Links {
+			if strings.EqualFold(l.Rel, "next_link") && !support.IsBlank(l.Href) {
+				next, err := base.Parse(l.Href)
+				if err != nil {
+					return nil, support.NewAPIError(what + " next_link 无法解析: " + l.Href)
+				}
+				u = next.String()
+			}
+		}
+	}
+	return out, nil
+}
@@ -39,9 +39,9 @@ func DownloadDriverMetadata(ctx context.Context, c *Client, token, u string) (ma
 	return obj, nil
 }
 
-func CreateShippingLabel(ctx context.Context, c *Client, token, productID, submissionID string, bodyObj map[string]any) (map[string]any, error) {
+func CreateShippingLabel(ctx context.Context, c *Client, token, productID, submissionID string, body any) (map[string]any, error) {
 	u := fmt.Sprintf("%s/products/%s/submissions/%s/shippingLabels", c.BaseAPI, productID, submissionID)
-	b := format.MustJSON(bodyObj)
+	b := format.MustJSON(body)
 
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -59,8 +59,78 @@ func CreateShippingLabel(ctx context.Context, c *Client, token, productID, submi
 		return nil, support.NewAPIError(fmt.Sprintf("POST /shippingLabels 失败: %d\n%s", resp.StatusCode, string(text)))
 	}
 
+	// Label IDs exceed float64 precision; keep numbers as json.Number.
 	var obj map[string]any
-	if err := json.Unmarshal(text, &obj); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return map[string]any{}, nil
+	}
+	return obj, nil
+}
+
+// ListShippingLabels returns the shipping labels of a submission (the "value"
+// arrays of all pages of GET .../shippingLabels).
+func ListShippingLabels(ctx context.Context, c *Client, token, productID, submissionID string) ([]map[string]any, error) {
+	u := fmt.Sprintf("%s/products/%s/submissions/%s/shippingLabels", c.BaseAPI, productID, submissionID)
+	return listPages(ctx, c, token, u, "shippingLabels")
+}
+
+// ListAudiences returns the audiences available to the account
+// (GET /audiences), used to restrict labels to pilot rings.
+func ListAudiences(ctx context.Context, c *Client, token string) ([]map[string]any, error) {
+	u := c.BaseAPI + "/audiences"
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, support.NewAPIError(fmt.Sprintf("GET /audiences 失败: %d\n%s", resp.StatusCode, string(body)))
+	}
+
+	var obj struct {
+		Value []map[string]any `json:"value"`
+	}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, support.NewAPIError("audiences 响应不是合法 JSON: " + err.Error())
+	}
+	return obj.Value, nil
+}
+
+// UpdateShippingLabel sends PATCH .../shippingLabels/{id} with a full label body.
+func UpdateShippingLabel(ctx context.Context, c *Client, token, productID, submissionID, labelID string, body any) (map[string]any, error) {
+	u := fmt.Sprintf("%s/products/%s/submissions/%s/shippingLabels/%s", c.BaseAPI, productID, submissionID, labelID)
+	b := format.MustJSON(body)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPatch, u, bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	text, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, support.NewAPIError(fmt.Sprintf("PATCH /shippingLabels/%s 失败: %d\n%s", labelID, resp.StatusCode, string(text)))
+	}
+
+	// Label IDs exceed float64 precision; keep numbers as json.Number.
+	var obj map[string]any
+	dec := json.NewDecoder(bytes.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
 		return map[string]any{}, nil
 	}
 	return obj, nil
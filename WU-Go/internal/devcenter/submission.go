@@ -85,3 +85,10 @@ func FindDriverMetadataURL(submission map[string]any) (string, error) {
 	}
 	return "", support.NewAPIError("submission 中未找到 driverMetadata URL（downloads.items 或 links 均没有）")
 }
+
+// ListSubmissions returns the submissions of a product (the "value" arrays
+// of all pages of GET /products/{id}/submissions).
+func ListSubmissions(ctx context.Context, c *Client, token, productID string) ([]map[string]any, error) {
+	u := fmt.Sprintf("%s/products/%s/submissions", c.BaseAPI, productID)
+	return listPages(ctx, c, token, u, "submissions")
+}
@@ -0,0 +1,194 @@
+package drivermeta
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"WU/internal/support"
+)
+
+// DiffItem is one INF/OS/PnP target in a metadata diff.
+type DiffItem struct {
+	OSCode            string `json:"operatingSystemCode"`
+	PnpID             string `json:"pnpString"`
+	Manufacturer      string `json:"manufacturer,omitempty"`
+	DeviceDescription string `json:"deviceDescription,omitempty"`
+}
+
+// DiffGroup collects the changes of one INF within a bundle. Bundle IDs
+// differ between submissions, so both sides are reported.
+type DiffGroup struct {
+	InfID        string     `json:"infId"`
+	OldBundleTag string     `json:"oldBundleTag,omitempty"`
+	OldBundleID  string     `json:"oldBundleId,omitempty"`
+	NewBundleTag string     `json:"newBundleTag,omitempty"`
+	NewBundleID  string     `json:"newBundleId,omitempty"`
+	Added        []DiffItem `json:"added"`
+	Removed      []DiffItem `json:"removed"`
+	Unchanged    []DiffItem `json:"unchanged"`
+}
+
+type DiffResult struct {
+	Added     int         `json:"added"`
+	Removed   int         `json:"removed"`
+	Unchanged int         `json:"unchanged"`
+	Groups    []DiffGroup `json:"groups"`
+}
+
+// Diff compares two parsed driverMetadata documents, grouped by bundle and
+// INF. Bundle IDs change with every submission, so an INF in the old
+// document is paired with the same INF (case-insensitive) in the new one;
+// when several bundles ship an INF, the bundles sharing the most targets
+// are paired first. Within a pair, targets are matched by OS code and PnP
+// ID (case-insensitive).
+func Diff(oldRes, newRes *ParseResult) *DiffResult {
+	oldUnits, oldOrder := diffUnits(oldRes.Targets)
+	newUnits, newOrder := diffUnits(newRes.Targets)
+
+	// Candidate pairs of the same INF, best overlap first.
+	type pair struct {
+		old, new *diffUnit
+		shared   int
+	}
+	var pairs []pair
+	for _, o := range oldOrder {
+		for _, n := range newOrder {
+			ou, nu := oldUnits[o], newUnits[n]
+			if !strings.EqualFold(ou.inf, nu.inf) {
+				continue
+			}
+			shared := 0
+			for k := range ou.keys {
+				if nu.keys[k] {
+					shared++
+				}
+			}
+			pairs = append(pairs, pair{ou, nu, shared})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].shared > pairs[j].shared })
+
+	res := &DiffResult{Groups: []DiffGroup{}}
+	paired := map[*diffUnit]bool{}
+	for _, p := range pairs {
+		if paired[p.old] || paired[p.new] {
+			continue
+		}
+		paired[p.old], paired[p.new] = true, true
+		res.add(p.old, p.new)
+	}
+	for _, o := range oldOrder {
+		if u := oldUnits[o]; !paired[u] {
+			res.add(u, nil)
+		}
+	}
+	for _, n := range newOrder {
+		if u := newUnits[n]; !paired[u] {
+			res.add(nil, u)
+		}
+	}
+
+	sort.SliceStable(res.Groups, func(i, j int) bool {
+		a, b := res.Groups[i], res.Groups[j]
+		at, bt := strings.ToLower(support.FirstNonEmpty(a.NewBundleTag, a.OldBundleTag)), strings.ToLower(support.FirstNonEmpty(b.NewBundleTag, b.OldBundleTag))
+		if at != bt {
+			return at < bt
+		}
+		return strings.ToLower(a.InfID) < strings.ToLower(b.InfID)
+	})
+	return res
+}
+
+// diffUnit is the targets of one INF within one bundle.
+type diffUnit struct {
+	inf, bundleID, bundleTag string
+	targets                  []HardwareTarget
+	keys                     map[string]bool // OS code|PnP ID, lower case
+}
+
+func diffTargetKey(t HardwareTarget) string {
+	return strings.ToLower(t.OSCode) + "|" + strings.ToLower(t.PnpID)
+}
+
+// diffUnits groups targets by DriverKey, keeping the order of first use.
+func diffUnits(targets []HardwareTarget) (map[string]*diffUnit, []string) {
+	units := map[string]*diffUnit{}
+	var order []string
+	for _, t := range targets {
+		k := DriverKey(t.BundleID, t.InfID)
+		u := units[k]
+		if u == nil {
+			u = &diffUnit{inf: t.InfID, bundleID: t.BundleID, bundleTag: t.BundleTag, keys: map[string]bool{}}
+			units[k] = u
+			order = append(order, k)
+		}
+		u.targets = append(u.targets, t)
+		u.keys[diffTargetKey(t)] = true
+	}
+	return units, order
+}
+
+// add appends the group comparing an old unit with a new one; either may
+// be nil.
+func (res *DiffResult) add(from, to *diffUnit) {
+	g := DiffGroup{Added: []DiffItem{}, Removed: []DiffItem{}, Unchanged: []DiffItem{}}
+	item := func(t HardwareTarget) DiffItem {
+		return DiffItem{OSCode: t.OSCode, PnpID: t.PnpID, Manufacturer: t.Manufacturer, DeviceDescription: t.DeviceDescription}
+	}
+	if from != nil {
+		g.InfID, g.OldBundleTag, g.OldBundleID = from.inf, from.bundleTag, from.bundleID
+		for _, t := range from.targets {
+			if to == nil || !to.keys[diffTargetKey(t)] {
+				g.Removed = append(g.Removed, item(t))
+				res.Removed++
+			}
+		}
+	}
+	if to != nil {
+		g.NewBundleTag, g.NewBundleID = to.bundleTag, to.bundleID
+		if g.InfID == "" {
+			g.InfID = to.inf
+		}
+		for _, t := range to.targets {
+			if from != nil && from.keys[diffTargetKey(t)] {
+				g.Unchanged = append(g.Unchanged, item(t))
+				res.Unchanged++
+			} else {
+				g.Added = append(g.Added, item(t))
+				res.Added++
+			}
+		}
+	}
+	res.Groups = append(res.Groups, g)
+}
+
+// WriteDiffText prints a diff as +/- lines grouped by bundle and INF.
+// Unchanged targets are counted, and listed only when verbose is set.
+func WriteDiffText(w io.Writer, d *DiffResult, verbose bool) {
+	for _, g := range d.Groups {
+		bundle := g.NewBundleTag
+		switch {
+		case g.NewBundleTag == "":
+			bundle = g.OldBundleTag + " → (removed)"
+		case g.OldBundleTag == "":
+			bundle = "(new) → " + g.NewBundleTag
+		case g.OldBundleTag != g.NewBundleTag:
+			bundle = g.OldBundleTag + " → " + g.NewBundleTag
+		}
+		fmt.Fprintf(w, "%s %s  +%d -%d =%d\n", bundle, g.InfID, len(g.Added), len(g.Removed), len(g.Unchanged))
+		for _, it := range g.Added {
+			fmt.Fprintf(w, "  + %s  %s\n", it.OSCode, it.PnpID)
+		}
+		for _, it := range g.Removed {
+			fmt.Fprintf(w, "  - %s  %s\n", it.OSCode, it.PnpID)
+		}
+		if verbose {
+			for _, it := range g.Unchanged {
+				fmt.Fprintf(w, "    %s  %s\n", it.OSCode, it.PnpID)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\nadded=%d removed=%d unchanged=%d\n", d.Added, d.Removed, d.Unchanged)
+}
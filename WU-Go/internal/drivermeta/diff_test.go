@@ -0,0 +1,165 @@
+package drivermeta
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	target := func(bundle, tag, inf, os, pnp string) HardwareTarget {
+		return HardwareTarget{BundleID: bundle, BundleTag: tag, InfID: inf, OSCode: os, PnpID: pnp}
+	}
+	const (
+		win11 = "WINDOWS_v100_X64_CO_FULL"
+		win10 = "WINDOWS_v100_X64_VB_FULL"
+	)
+	// groupLines renders each group as "oldTag→newTag inf +[added] -[removed] =[unchanged]".
+	groupLines := func(d *DiffResult) []string {
+		var out []string
+		for _, g := range d.Groups {
+			items := func(its []DiffItem) string {
+				var s []string
+				for _, it := range its {
+					s = append(s, it.OSCode+"/"+it.PnpID)
+				}
+				return strings.Join(s, ",")
+			}
+			out = append(out, fmt.Sprintf("%s→%s %s +[%s] -[%s] =[%s]",
+				g.OldBundleTag, g.NewBundleTag, g.InfID, items(g.Added), items(g.Removed), items(g.Unchanged)))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name                      string
+		old, new                  []HardwareTarget
+		added, removed, unchanged int
+		groups                    []string
+	}{
+		{
+			name:      "identical apart from bundle IDs",
+			old:       []HardwareTarget{target("b-old", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`)},
+			new:       []HardwareTarget{target("b-new", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`)},
+			unchanged: 1,
+			groups:    []string{`B1→B1 net.inf +[] -[] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001]`},
+		},
+		{
+			name:      "keys are case-insensitive",
+			old:       []HardwareTarget{target("b1", "B1", "NET.INF", strings.ToLower(win11), `pci\ven_8086&dev_0001`)},
+			new:       []HardwareTarget{target("b2", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`)},
+			unchanged: 1,
+			groups:    []string{`B1→B1 NET.INF +[] -[] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001]`},
+		},
+		{
+			name: "added and removed targets",
+			old: []HardwareTarget{
+				target("b1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`),
+				target("b1", "B1", "net.inf", win10, `PCI\VEN_8086&DEV_0001`),
+			},
+			new: []HardwareTarget{
+				target("b2", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`),
+				target("b2", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0002`),
+			},
+			added: 1, removed: 1, unchanged: 1,
+			groups: []string{`B1→B1 net.inf +[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0002] -[WINDOWS_v100_X64_VB_FULL/PCI\VEN_8086&DEV_0001] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001]`},
+		},
+		{
+			name:    "changed PnP ID counts as removed and added",
+			old:     []HardwareTarget{target("b1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`)},
+			new:     []HardwareTarget{target("b2", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001&SUBSYS_1234`)},
+			added:   1,
+			removed: 1,
+			groups:  []string{`B1→B1 net.inf +[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001&SUBSYS_1234] -[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001] =[]`},
+		},
+		{
+			name: "INFs dropped, new and moved between bundles, sorted by bundle tag",
+			old: []HardwareTarget{
+				target("b1", "B2", "old.inf", win11, `USB\VID_1234`),
+				target("b1", "B2", "audio.inf", win11, `HDAUDIO\FUNC_01`),
+			},
+			new: []HardwareTarget{
+				target("b2", "B1", "audio.inf", win11, `HDAUDIO\FUNC_01`),
+				target("b3", "B3", "new.inf", win11, `ACPI\ABC0001`),
+			},
+			added: 1, removed: 1, unchanged: 1,
+			groups: []string{
+				`B2→B1 audio.inf +[] -[] =[WINDOWS_v100_X64_CO_FULL/HDAUDIO\FUNC_01]`,
+				`B2→ old.inf +[] -[WINDOWS_v100_X64_CO_FULL/USB\VID_1234] =[]`,
+				`→B3 new.inf +[WINDOWS_v100_X64_CO_FULL/ACPI\ABC0001] -[] =[]`,
+			},
+		},
+		{
+			name: "same INF in two bundles is compared per bundle",
+			old: []HardwareTarget{
+				target("b1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`),
+				target("b2", "B2", "net.inf", win11, `PCI\VEN_8086&DEV_0002`),
+			},
+			new: []HardwareTarget{
+				target("n1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`),
+				target("n1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0003`),
+				target("n2", "B2", "net.inf", win11, `PCI\VEN_8086&DEV_0002`),
+			},
+			added: 1, unchanged: 2,
+			groups: []string{
+				`B1→B1 net.inf +[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0003] -[] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001]`,
+				`B2→B2 net.inf +[] -[] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0002]`,
+			},
+		},
+		{
+			name: "bundles sharing an INF are paired by their targets, not their tags",
+			old: []HardwareTarget{
+				target("b1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0001`),
+				target("b2", "B2", "net.inf", win11, `PCI\VEN_8086&DEV_0002`),
+				target("b2", "B2", "net.inf", win10, `PCI\VEN_8086&DEV_0002`),
+			},
+			new: []HardwareTarget{
+				target("n1", "B1", "net.inf", win11, `PCI\VEN_8086&DEV_0002`),
+				target("n2", "B2", "net.inf", win11, `PCI\VEN_8086&DEV_0001`),
+			},
+			removed: 1, unchanged: 2,
+			groups: []string{
+				`B2→B1 net.inf +[] -[WINDOWS_v100_X64_VB_FULL/PCI\VEN_8086&DEV_0002] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0002]`,
+				`B1→B2 net.inf +[] -[] =[WINDOWS_v100_X64_CO_FULL/PCI\VEN_8086&DEV_0001]`,
+			},
+		},
+		{
+			name:   "empty on both sides",
+			groups: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diff(&ParseResult{Targets: tt.old}, &ParseResult{Targets: tt.new})
+			if d.Added != tt.added || d.Removed != tt.removed || d.Unchanged != tt.unchanged {
+				t.Errorf("counts = +%d -%d =%d, want +%d -%d =%d", d.Added, d.Removed, d.Unchanged, tt.added, tt.removed, tt.unchanged)
+			}
+			if got := groupLines(d); !slices.Equal(got, tt.groups) {
+				t.Errorf("groups:\n  %s\nwant:\n  %s", strings.Join(got, "\n  "), strings.Join(tt.groups, "\n  "))
+			}
+		})
+	}
+}
+
+func TestWriteDiffText(t *testing.T) {
+	d := Diff(
+		&ParseResult{Targets: []HardwareTarget{
+			{BundleTag: "B1", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_CO_FULL", PnpID: `PCI\VEN_8086&DEV_0001`},
+			{BundleTag: "B1", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_CO_FULL", PnpID: `PCI\VEN_8086&DEV_0002`},
+		}},
+		&ParseResult{Targets: []HardwareTarget{
+			{BundleTag: "B2", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_CO_FULL", PnpID: `PCI\VEN_8086&DEV_0001`},
+			{BundleTag: "B2", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_CO_FULL", PnpID: `PCI\VEN_8086&DEV_0003`},
+		}},
+	)
+	var sb strings.Builder
+	WriteDiffText(&sb, d, false)
+	want := "B1 → B2 net.inf  +1 -1 =1\n" +
+		"  + WINDOWS_v100_X64_CO_FULL  PCI\\VEN_8086&DEV_0003\n" +
+		"  - WINDOWS_v100_X64_CO_FULL  PCI\\VEN_8086&DEV_0002\n" +
+		"\nadded=1 removed=1 unchanged=1\n"
+	if sb.String() != want {
+		t.Errorf("WriteDiffText() =\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
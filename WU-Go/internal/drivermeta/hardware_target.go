@@ -1,11 +1,12 @@
 package drivermeta
 
 type HardwareTarget struct {
-	BundleID          string
-	BundleTag         string
-	InfID             string
-	OSCode            string
-	PnpID             string
-	Manufacturer      string
-	DeviceDescription string
+	BundleID          string `json:"bundleId"`
+	BundleTag         string `json:"bundleTag"`
+	InfID             string `json:"infId"`
+	OSCode            string `json:"operatingSystemCode"`
+	PnpID             string `json:"pnpString"`
+	Manufacturer      string `json:"manufacturer,omitempty"`
+	DeviceDescription string `json:"deviceDescription,omitempty"`
+	DriverVersion     string `json:"driverVersion,omitempty"`
 }
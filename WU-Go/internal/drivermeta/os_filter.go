@@ -0,0 +1,189 @@
+package drivermeta
+
+import (
+	"regexp"
+	"strings"
+
+	"WU/internal/support"
+)
+
+// OSFilter is a conjunction of OS conditions such as
+// "arch=x64,arm64 release>=21H2 server=no".
+//
+// Keys: arch, release, windows (10/11), edition, server (yes/no), code
+// (substring of the raw OS code). release and windows also accept
+// >=, <=, > and <.
+type OSFilter struct {
+	terms []osTerm
+}
+
+type osTerm struct {
+	key    string
+	op     string
+	values []string
+}
+
+var osTermRegex = regexp.MustCompile(`^([A-Za-z]+)\s*(>=|<=|!=|=|>|<)\s*(.+)$`)
+
+// LooksLikeOSFilter reports whether s uses the key=value filter syntax rather
+// than being a plain keyword.
+func LooksLikeOSFilter(s string) bool {
+	for _, f := range strings.Fields(s) {
+		if m := osTermRegex.FindStringSubmatch(f); m != nil && isOSFilterKey(m[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOSFilterKey(k string) bool {
+	switch strings.ToLower(k) {
+	case "arch", "release", "windows", "edition", "server", "code":
+		return true
+	}
+	return false
+}
+
+func ParseOSFilter(expr string) (*OSFilter, error) {
+	f := &OSFilter{}
+	for _, field := range strings.Fields(expr) {
+		m := osTermRegex.FindStringSubmatch(field)
+		if m == nil || !isOSFilterKey(m[1]) {
+			return nil, support.NewAPIError("无法解析 OS 过滤条件: " + field + "（示例: arch=x64 release>=21H2）")
+		}
+		t := osTerm{key: strings.ToLower(m[1]), op: m[2]}
+		for _, v := range strings.Split(m[3], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				t.values = append(t.values, v)
+			}
+		}
+		if len(t.values) == 0 {
+			return nil, support.NewAPIError("OS 过滤条件缺少取值: " + field)
+		}
+		ordered := t.key == "release" || t.key == "windows"
+		if !ordered && t.op != "=" && t.op != "!=" {
+			return nil, support.NewAPIError("OS 过滤条件 " + t.key + " 只支持 = 或 !=: " + field)
+		}
+		if ordered && t.op != "=" && t.op != "!=" && len(t.values) != 1 {
+			return nil, support.NewAPIError("比较运算只能有一个取值: " + field)
+		}
+		if t.key == "release" {
+			for _, v := range t.values {
+				if _, ok := ParseReleaseRank(v); !ok {
+					return nil, support.NewAPIError("未知的 release: " + v + "（可用: " + strings.Join(ReleaseTokens(), ", ") + " 或 1809/21H2 形式）")
+				}
+			}
+		}
+		f.terms = append(f.terms, t)
+	}
+	return f, nil
+}
+
+// Empty reports whether the filter has no conditions.
+func (f *OSFilter) Empty() bool { return f == nil || len(f.terms) == 0 }
+
+func (f *OSFilter) Match(info OSInfo) bool {
+	if f == nil {
+		return true
+	}
+	for _, t := range f.terms {
+		if !t.match(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterTargetsByOS keeps the targets whose OS code matches f.
+func FilterTargetsByOS(targets []HardwareTarget, f *OSFilter) []HardwareTarget {
+	if f.Empty() {
+		return targets
+	}
+	out := make([]HardwareTarget, 0, len(targets))
+	for _, t := range targets {
+		if f.Match(ParseOSCode(t.OSCode)) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (t osTerm) match(info OSInfo) bool {
+	any := false
+	for _, v := range t.values {
+		if t.matchOne(info, v) {
+			any = true
+			break
+		}
+	}
+	if t.op == "!=" {
+		return !any
+	}
+	return any
+}
+
+func (t osTerm) matchOne(info OSInfo, v string) bool {
+	switch t.key {
+	case "arch":
+		want := strings.ToLower(v)
+		if a, ok := archTokens[strings.ToUpper(v)]; ok {
+			want = a
+		}
+		return info.Arch == want
+	case "edition":
+		return strings.EqualFold(info.Edition, v)
+	case "code":
+		return support.ContainsLower(info.Code, strings.ToLower(v))
+	case "server":
+		want := false
+		switch strings.ToLower(v) {
+		case "y", "yes", "1", "true":
+			want = true
+		}
+		return info.Server == want
+	case "windows":
+		have := windowsMajor(info)
+		want := support.ToLower(v)
+		if t.op == "=" || t.op == "!=" {
+			return have == want
+		}
+		return compareInts(releaseRank(have), t.op, releaseRank(want))
+	case "release":
+		want, _ := ParseReleaseRank(v)
+		have := info.Rank()
+		if t.op == "=" || t.op == "!=" {
+			if r, ok := lookupRelease(v); ok {
+				return strings.EqualFold(info.Release, r.Token)
+			}
+			return have == want
+		}
+		if have == 0 {
+			return false
+		}
+		return compareInts(have, t.op, want)
+	}
+	return false
+}
+
+// windowsMajor returns "10", "11", "8.1" ... for the windows= key.
+func windowsMajor(info OSInfo) string {
+	p := info.Product()
+	if strings.HasPrefix(p, "Windows Server") {
+		return "server"
+	}
+	return strings.ToLower(strings.TrimPrefix(p, "Windows "))
+}
+
+func compareInts(a int, op string, b int) bool {
+	switch op {
+	case ">=":
+		return a >= b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case "<":
+		return a < b
+	}
+	return a == b
+}
@@ -0,0 +1,292 @@
+package drivermeta
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"WU/internal/support"
+)
+
+// OSInfo is the decoded form of a Dev Center operatingSystemCode such as
+// WINDOWS_v100_X64_CO_FULL.
+type OSInfo struct {
+	Code    string
+	Version string // NT version from the vNNN token, e.g. "10.0"
+	Release string // release token, e.g. "CO"; empty for pre-10 codes
+	Arch    string // x86 / x64 / arm / arm64
+	Edition string // trailing token(s), e.g. "FULL"
+	Server  bool
+	Known   bool // false when the code did not follow the WINDOWS_vNNN_... shape
+}
+
+type osRelease struct {
+	Token  string
+	Label  string // marketing version: 1809, 21H2 ...
+	Client string
+	Server string
+}
+
+// Release tokens used in OS codes, oldest first. The inServicePublishInfo
+// flooring/ceiling values use the same tokens.
+var osReleases = []osRelease{
+	{Token: "TH1", Label: "1507", Client: "Windows 10"},
+	{Token: "TH2", Label: "1511", Client: "Windows 10"},
+	{Token: "RS1", Label: "1607", Client: "Windows 10", Server: "Windows Server 2016"},
+	{Token: "RS2", Label: "1703", Client: "Windows 10"},
+	{Token: "RS3", Label: "1709", Client: "Windows 10"},
+	{Token: "RS4", Label: "1803", Client: "Windows 10"},
+	{Token: "RS5", Label: "1809", Client: "Windows 10", Server: "Windows Server 2019"},
+	{Token: "19H1", Label: "1903", Client: "Windows 10"},
+	{Token: "VB", Label: "2004", Client: "Windows 10"},
+	{Token: "FE", Label: "21H2", Server: "Windows Server 2022"}, // server only
+	{Token: "CO", Label: "21H2", Client: "Windows 11"},
+	{Token: "NI", Label: "22H2", Client: "Windows 11"},
+	{Token: "GE", Label: "24H2", Client: "Windows 11", Server: "Windows Server 2025"},
+}
+
+var legacyVersions = map[string]struct{ Client, Server string }{
+	"v61": {"Windows 7", "Windows Server 2008 R2"},
+	"v62": {"Windows 8", "Windows Server 2012"},
+	"v63": {"Windows 8.1", "Windows Server 2012 R2"},
+}
+
+var archTokens = map[string]string{
+	"X86":   "x86",
+	"X64":   "x64",
+	"AMD64": "x64",
+	"ARM":   "arm",
+	"ARM64": "arm64",
+}
+
+func lookupRelease(token string) (osRelease, bool) {
+	t := strings.ToUpper(strings.TrimSpace(token))
+	if t == "TH" {
+		t = "TH1"
+	}
+	for _, r := range osReleases {
+		if r.Token == t {
+			return r, true
+		}
+	}
+	return osRelease{}, false
+}
+
+// ParseOSCode splits an OS code into version, release, architecture and
+// edition. Unknown shapes are returned with Known=false and Code set.
+func ParseOSCode(code string) OSInfo {
+	info := OSInfo{Code: code}
+	tokens := strings.Split(strings.TrimSpace(code), "_")
+	if len(tokens) < 2 || !strings.EqualFold(tokens[0], "WINDOWS") || !strings.HasPrefix(strings.ToLower(tokens[1]), "v") {
+		return info
+	}
+
+	ver := strings.ToLower(tokens[1])
+	info.Version = ver[1:]
+	if n, err := strconv.Atoi(info.Version); err == nil && len(info.Version) >= 2 {
+		info.Version = strconv.Itoa(n/10) + "." + strconv.Itoa(n%10)
+	}
+
+	var edition []string
+	for _, t := range tokens[2:] {
+		up := strings.ToUpper(t)
+		if up == "SERVER" {
+			info.Server = true
+			continue
+		}
+		if a, ok := archTokens[up]; ok && info.Arch == "" {
+			info.Arch = a
+			continue
+		}
+		if _, ok := lookupRelease(up); ok && info.Release == "" {
+			info.Release = up
+			continue
+		}
+		edition = append(edition, up)
+	}
+	info.Edition = strings.Join(edition, "_")
+	if info.Arch == "" {
+		info.Arch = "x86"
+	}
+	info.Known = true
+	return info
+}
+
+// ReleaseLabel returns the marketing version (e.g. "22H2"), or the raw
+// release token when it is not in the table.
+func (o OSInfo) ReleaseLabel() string {
+	if r, ok := lookupRelease(o.Release); ok {
+		return r.Label
+	}
+	return o.Release
+}
+
+// Product returns the Windows product name, e.g. "Windows 11" or
+// "Windows Server 2022".
+func (o OSInfo) Product() string {
+	if l, ok := legacyVersions["v"+strings.ReplaceAll(o.Version, ".", "")]; ok {
+		if o.Server {
+			return l.Server
+		}
+		return l.Client
+	}
+	r, ok := lookupRelease(o.Release)
+	if !ok {
+		if o.Server {
+			return "Windows Server"
+		}
+		return "Windows " + o.Version
+	}
+	if o.Server || r.Client == "" {
+		return support.Or(r.Server, "Windows Server "+r.Label)
+	}
+	return r.Client
+}
+
+// FriendlyName returns e.g. "Windows 11 22H2 x64" or "Windows Server 2022 x64".
+func (o OSInfo) FriendlyName() string {
+	if !o.Known {
+		return o.Code
+	}
+	parts := []string{o.Product()}
+	if r, ok := lookupRelease(o.Release); ok && !((o.Server || r.Client == "") && r.Server != "") {
+		parts = append(parts, r.Label)
+	}
+	parts = append(parts, o.Arch)
+	return strings.Join(parts, " ")
+}
+
+// ShortName is a compact form for list columns, e.g. "W11 22H2 x64".
+func (o OSInfo) ShortName() string {
+	if !o.Known {
+		return o.Code
+	}
+	name := o.FriendlyName()
+	name = strings.Replace(name, "Windows Server ", "WS", 1)
+	name = strings.Replace(name, "Windows ", "W", 1)
+	return name
+}
+
+// Rank orders releases chronologically (1507 < 1809 < 21H2 < 24H2).
+// Legacy and unknown releases rank 0.
+func (o OSInfo) Rank() int {
+	r, ok := lookupRelease(o.Release)
+	if !ok {
+		return 0
+	}
+	return releaseRank(r.Label)
+}
+
+// releaseRank turns "1809" into 1809 and "22H2" into 2212 so both styles
+// compare numerically.
+func releaseRank(label string) int {
+	s := strings.ToUpper(strings.TrimSpace(label))
+	if len(s) == 4 && s[2] == 'H' {
+		yy, err1 := strconv.Atoi(s[:2])
+		h, err2 := strconv.Atoi(s[3:])
+		if err1 != nil || err2 != nil {
+			return 0
+		}
+		return yy*100 + h*6
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// ParseReleaseRank accepts a marketing version (21H2, 1809) or a release
+// token (CO, RS5) and returns its rank.
+func ParseReleaseRank(v string) (int, bool) {
+	if r, ok := lookupRelease(v); ok {
+		return releaseRank(r.Label), true
+	}
+	if n := releaseRank(v); n > 0 {
+		return n, true
+	}
+	return 0, false
+}
+
+// ResolveReleaseToken maps a release token (RS5, co) or marketing version
+// (1809, 22H2) to its canonical token. When a version is shared, the newest
+// client release wins (21H2 → CO).
+func ResolveReleaseToken(v string) (string, bool) {
+	if r, ok := lookupRelease(v); ok {
+		return r.Token, true
+	}
+	want := strings.ToUpper(strings.TrimSpace(v))
+	for i := len(osReleases) - 1; i >= 0; i-- {
+		if osReleases[i].Label == want {
+			return osReleases[i].Token, true
+		}
+	}
+	return "", false
+}
+
+// DescribeRelease returns "RS5 (Windows 10 1809)" style text for a token.
+func DescribeRelease(token string) string {
+	r, ok := lookupRelease(token)
+	if !ok {
+		return token
+	}
+	if r.Client == "" {
+		return r.Token + " (" + r.Server + ")"
+	}
+	return r.Token + " (" + r.Client + " " + r.Label + ")"
+}
+
+// ReleaseTokens lists the known release tokens, oldest first.
+func ReleaseTokens() []string {
+	out := make([]string, 0, len(osReleases))
+	for _, r := range osReleases {
+		out = append(out, r.Token)
+	}
+	return out
+}
+
+// OSGroup is a set of targets sharing one OS code.
+type OSGroup struct {
+	Info    OSInfo
+	Targets []HardwareTarget
+}
+
+// SortTargetsByOS orders targets by release, architecture and OS code,
+// keeping the existing bundle/INF/PnP order inside each OS.
+func SortTargetsByOS(targets []HardwareTarget) []HardwareTarget {
+	out := append([]HardwareTarget{}, targets...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return osLess(ParseOSCode(out[i].OSCode), ParseOSCode(out[j].OSCode))
+	})
+	return out
+}
+
+// GroupTargetsByOS groups targets by OS code in SortTargetsByOS order.
+func GroupTargetsByOS(targets []HardwareTarget) []OSGroup {
+	groups := []OSGroup{}
+	index := map[string]int{}
+	for _, t := range SortTargetsByOS(targets) {
+		key := strings.ToLower(t.OSCode)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, OSGroup{Info: ParseOSCode(t.OSCode)})
+		}
+		groups[i].Targets = append(groups[i].Targets, t)
+	}
+	return groups
+}
+
+func osLess(a, b OSInfo) bool {
+	if a.Rank() != b.Rank() {
+		return a.Rank() < b.Rank()
+	}
+	if a.Server != b.Server {
+		return !a.Server
+	}
+	if a.Arch != b.Arch {
+		return a.Arch < b.Arch
+	}
+	return strings.ToLower(a.Code) < strings.ToLower(b.Code)
+}
@@ -0,0 +1,63 @@
+package drivermeta
+
+import "testing"
+
+func TestParseOSCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		arch     string
+		release  string
+		server   bool
+		friendly string
+	}{
+		{"WINDOWS_v100_X64_CO_FULL", "x64", "CO", false, "Windows 11 21H2 x64"},
+		{"WINDOWS_v100_ARM64_GE_FULL", "arm64", "GE", false, "Windows 11 24H2 arm64"},
+		{"WINDOWS_v100_TH2_FULL", "x86", "TH2", false, "Windows 10 1511 x86"},
+		{"WINDOWS_v100_SERVER_X64_FE_FULL", "x64", "FE", true, "Windows Server 2022 x64"},
+		// FE shipped only as Windows Server 2022, never as Windows 10 21H2
+		{"WINDOWS_v100_X64_FE_FULL", "x64", "FE", false, "Windows Server 2022 x64"},
+		{"WINDOWS_v63_X64", "x64", "", false, "Windows 8.1 x64"},
+	}
+	for _, tt := range tests {
+		got := ParseOSCode(tt.code)
+		if !got.Known || got.Arch != tt.arch || got.Release != tt.release || got.Server != tt.server {
+			t.Errorf("ParseOSCode(%q) = %+v", tt.code, got)
+		}
+		if f := got.FriendlyName(); f != tt.friendly {
+			t.Errorf("FriendlyName(%q) = %q, want %q", tt.code, f, tt.friendly)
+		}
+	}
+
+	if d := DescribeRelease("FE"); d != "FE (Windows Server 2022)" {
+		t.Errorf("DescribeRelease(FE) = %q", d)
+	}
+
+	if ParseOSCode("SOMETHING_ELSE").Known {
+		t.Errorf("unexpected Known for non-Windows code")
+	}
+}
+
+func TestOSFilter(t *testing.T) {
+	f, err := ParseOSFilter("arch=x64 release>=21H2 server=no")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"WINDOWS_v100_X64_NI_FULL":        true,
+		"WINDOWS_v100_X64_CO_FULL":        true,
+		"WINDOWS_v100_X64_RS5_FULL":       false,
+		"WINDOWS_v100_ARM64_NI_FULL":      false,
+		"WINDOWS_v100_SERVER_X64_GE_FULL": false,
+	}
+	for code, want := range cases {
+		if got := f.Match(ParseOSCode(code)); got != want {
+			t.Errorf("Match(%q) = %v, want %v", code, got, want)
+		}
+	}
+
+	for _, bad := range []string{"arch>x64", "release>=ZZ", "foo=bar"} {
+		if _, err := ParseOSFilter(bad); err == nil {
+			t.Errorf("ParseOSFilter(%q) expected error", bad)
+		}
+	}
+}
@@ -74,6 +74,8 @@ func Parse(metaRoot map[string]any) (*ParseResult, error) {
 				continue
 			}
 
+			driverVersion, _ := infObj["DriverVersion"].(string)
+
 			osPnpInfoMap, _ := infObj["OSPnPInfoMap"].(map[string]any)
 			if osPnpInfoMap == nil {
 				continue
@@ -106,6 +108,7 @@ func Parse(metaRoot map[string]any) (*ParseResult, error) {
 						PnpID: pnpID,
 						Manufacturer: manufacturer,
 						DeviceDescription: deviceDesc,
+						DriverVersion: driverVersion,
 					})
 					countByBundle[bundleID]++
 				}
@@ -179,7 +182,7 @@ func BuildListItems(items []HardwareTarget, ui BundleUIMapping) []tui.ListItem {
 		color := ui.BundleColorByID[c.BundleID]
 		b := support.PadRight(support.Or(c.BundleTag, ""), 3)
 		inf := format.Fit(c.InfID, infW)
-		os := format.Fit(c.OSCode, osW)
+		os := format.Fit(ParseOSCode(c.OSCode).ShortName(), osW)
 		pnp := format.Fit(c.PnpID, pnpW)
 
 		extraParts := []string{}
@@ -202,8 +205,34 @@ func BuildListItems(items []HardwareTarget, ui BundleUIMapping) []tui.ListItem {
 		}
 
 		out = append(out, tui.ListItem{
-			Text:  text,
-			Color: tui.Color(color),
+			Text:   text,
+			Color:  tui.Color(color),
+			Filter: strings.Join([]string{c.BundleTag, c.InfID, c.OSCode, ParseOSCode(c.OSCode).ShortName(), c.PnpID, c.Manufacturer, c.DeviceDescription}, " "),
+			Bundle: c.BundleID,
+			Inf:    DriverKey(c.BundleID, c.InfID),
+			OS:     c.OSCode,
+			Columns: []tui.Field{
+				{Name: "INF", Value: c.InfID},
+				{Name: "OS code", Value: c.OSCode},
+				{Name: "PnP ID", Value: c.PnpID},
+				{Name: "Manufacturer", Value: c.Manufacturer},
+				{Name: "Description", Value: c.DeviceDescription},
+			},
+			Tree: []string{
+				strings.TrimSpace(c.BundleTag + " " + c.BundleID),
+				strings.TrimSpace(c.InfID + "  " + c.DriverVersion),
+				ParseOSCode(c.OSCode).FriendlyName() + "  (" + c.OSCode + ")",
+			},
+			Leaf: strings.TrimSpace(c.PnpID + "  " + extra),
+			Details: []tui.Field{
+				{Name: "Bundle", Value: strings.TrimSpace(c.BundleTag + " " + c.BundleID)},
+				{Name: "INF", Value: c.InfID},
+				{Name: "OS", Value: c.OSCode + "  (" + ParseOSCode(c.OSCode).FriendlyName() + ")"},
+				{Name: "PnP ID", Value: c.PnpID},
+				{Name: "Manufacturer", Value: c.Manufacturer},
+				{Name: "Description", Value: c.DeviceDescription},
+				{Name: "Driver", Value: c.DriverVersion},
+			},
 		})
 	}
 	return out
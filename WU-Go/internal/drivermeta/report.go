@@ -0,0 +1,201 @@
+package drivermeta
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTree prints targets as bundle → INF → OS → PnP. Targets are expected
+// in Parse order (bundle tag, INF, OS, PnP).
+func WriteTree(w io.Writer, res *ParseResult) {
+	type osNode struct {
+		code  string
+		items []HardwareTarget
+	}
+	type infNode struct {
+		id  string
+		oss []*osNode
+	}
+	type bundleNode struct {
+		id, tag string
+		infs    []*infNode
+		count   int
+	}
+
+	var bundles []*bundleNode
+	for _, t := range res.Targets {
+		if len(bundles) == 0 || bundles[len(bundles)-1].id != t.BundleID {
+			bundles = append(bundles, &bundleNode{id: t.BundleID, tag: t.BundleTag})
+		}
+		b := bundles[len(bundles)-1]
+		b.count++
+		if len(b.infs) == 0 || !strings.EqualFold(b.infs[len(b.infs)-1].id, t.InfID) {
+			b.infs = append(b.infs, &infNode{id: t.InfID})
+		}
+		inf := b.infs[len(b.infs)-1]
+		if len(inf.oss) == 0 || !strings.EqualFold(inf.oss[len(inf.oss)-1].code, t.OSCode) {
+			inf.oss = append(inf.oss, &osNode{code: t.OSCode})
+		}
+		o := inf.oss[len(inf.oss)-1]
+		o.items = append(o.items, t)
+	}
+
+	branch := func(last bool) (string, string) {
+		if last {
+			return "└─ ", "   "
+		}
+		return "├─ ", "│  "
+	}
+
+	for _, b := range bundles {
+		fmt.Fprintf(w, "%s %s  (%d targets)\n", b.tag, b.id, b.count)
+		for i, inf := range b.infs {
+			p1, c1 := branch(i == len(b.infs)-1)
+			fmt.Fprintf(w, "%s%s\n", p1, inf.id)
+			for j, o := range inf.oss {
+				p2, c2 := branch(j == len(inf.oss)-1)
+				fmt.Fprintf(w, "%s%s%s  [%s]\n", c1, p2, ParseOSCode(o.code).FriendlyName(), o.code)
+				for k, t := range o.items {
+					p3, _ := branch(k == len(o.items)-1)
+					fmt.Fprintf(w, "%s%s%s%s%s\n", c1, c2, p3, t.PnpID, describeDevice(t))
+				}
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// WriteStats prints target, INF, OS and PnP counts per bundle and per OS.
+func WriteStats(w io.Writer, res *ParseResult) {
+	type stat struct {
+		targets int
+		infs    map[string]bool
+		oss     map[string]bool
+		pnps    map[string]bool
+	}
+	newStat := func() *stat {
+		return &stat{infs: map[string]bool{}, oss: map[string]bool{}, pnps: map[string]bool{}}
+	}
+	byBundle := map[string]*stat{}
+	for _, t := range res.Targets {
+		s := byBundle[t.BundleID]
+		if s == nil {
+			s = newStat()
+			byBundle[t.BundleID] = s
+		}
+		s.targets++
+		s.infs[strings.ToLower(t.InfID)] = true
+		s.oss[strings.ToLower(t.OSCode)] = true
+		s.pnps[strings.ToLower(t.PnpID)] = true
+	}
+
+	fmt.Fprintf(w, "%-4s %-38s %8s %5s %5s %6s\n", "Tag", "Bundle", "Targets", "INFs", "OSes", "PnPs")
+	for _, l := range res.UI.Legends {
+		s := byBundle[l.BundleID]
+		if s == nil {
+			s = newStat()
+		}
+		fmt.Fprintf(w, "%-4s %-38s %8d %5d %5d %6d\n", l.Tag, l.BundleID, s.targets, len(s.infs), len(s.oss), len(s.pnps))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "%-28s %-34s %8s %6s\n", "OS", "Code", "Targets", "PnPs")
+	for _, g := range GroupTargetsByOS(res.Targets) {
+		pnps := map[string]bool{}
+		for _, t := range g.Targets {
+			pnps[strings.ToLower(t.PnpID)] = true
+		}
+		fmt.Fprintf(w, "%-28s %-34s %8d %6d\n", g.Info.FriendlyName(), g.Info.Code, len(g.Targets), len(pnps))
+	}
+	fmt.Fprintf(w, "\nTotal: %d targets in %d bundles\n", len(res.Targets), len(res.UI.Legends))
+}
+
+// exportRow is the flat target shape written by the export formats.
+type exportRow struct {
+	BundleTag         string `json:"bundleTag"`
+	BundleID          string `json:"bundleId"`
+	InfID             string `json:"infId"`
+	OSCode            string `json:"operatingSystemCode"`
+	OS                string `json:"os"`
+	PnpID             string `json:"pnpString"`
+	Manufacturer      string `json:"manufacturer"`
+	DeviceDescription string `json:"deviceDescription"`
+}
+
+var exportHeader = []string{"bundleTag", "bundleId", "infId", "operatingSystemCode", "os", "pnpString", "manufacturer", "deviceDescription"}
+
+func (r exportRow) fields() []string {
+	return []string{r.BundleTag, r.BundleID, r.InfID, r.OSCode, r.OS, r.PnpID, r.Manufacturer, r.DeviceDescription}
+}
+
+func exportRows(targets []HardwareTarget) []exportRow {
+	out := make([]exportRow, 0, len(targets))
+	for _, t := range targets {
+		out = append(out, exportRow{
+			BundleTag:         t.BundleTag,
+			BundleID:          t.BundleID,
+			InfID:             t.InfID,
+			OSCode:            t.OSCode,
+			OS:                ParseOSCode(t.OSCode).FriendlyName(),
+			PnpID:             t.PnpID,
+			Manufacturer:      strings.TrimSpace(t.Manufacturer),
+			DeviceDescription: strings.TrimSpace(t.DeviceDescription),
+		})
+	}
+	return out
+}
+
+// ExportFormats lists the formats accepted by WriteExport.
+var ExportFormats = []string{"csv", "json", "md"}
+
+// WriteExport writes the flat target list as csv, json or md (Markdown table).
+func WriteExport(w io.Writer, targets []HardwareTarget, format string) error {
+	rows := exportRows(targets)
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportHeader); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := cw.Write(r.fields()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(rows)
+	case "md":
+		fmt.Fprintln(w, "| "+strings.Join(exportHeader, " | ")+" |")
+		fmt.Fprintln(w, "|"+strings.Repeat(" --- |", len(exportHeader)))
+		for _, r := range rows {
+			cells := r.fields()
+			for i, c := range cells {
+				cells[i] = strings.ReplaceAll(strings.ReplaceAll(c, "\\", "\\\\"), "|", "\\|")
+			}
+			fmt.Fprintln(w, "| "+strings.Join(cells, " | ")+" |")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported export format: %s", format)
+}
+
+func describeDevice(t HardwareTarget) string {
+	parts := []string{}
+	for _, s := range []string{t.Manufacturer, t.DeviceDescription} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  " + strings.Join(parts, " | ")
+}
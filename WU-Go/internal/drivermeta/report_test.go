@@ -0,0 +1,116 @@
+package drivermeta
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// reportResult is two bundles: B1 with one INF on two OSes, B2 with one target.
+func reportResult() *ParseResult {
+	return &ParseResult{
+		Targets: []HardwareTarget{
+			{BundleID: "b1", BundleTag: "B1", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_CO_FULL", PnpID: `PCI\VEN_8086&DEV_0001`, Manufacturer: " Contoso ", DeviceDescription: "Contoso NIC"},
+			{BundleID: "b1", BundleTag: "B1", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_CO_FULL", PnpID: `PCI\VEN_8086&DEV_0002`},
+			{BundleID: "b1", BundleTag: "B1", InfID: "net.inf", OSCode: "WINDOWS_v100_X64_NI_FULL", PnpID: `PCI\VEN_8086&DEV_0001`, Manufacturer: "Contoso", DeviceDescription: "Contoso NIC"},
+			{BundleID: "b2", BundleTag: "B2", InfID: "wlan.inf", OSCode: "WINDOWS_v100_SERVER_X64_FE_FULL", PnpID: `PCI\VEN_8086&DEV_00F0`, DeviceDescription: "Wireless | AX"},
+		},
+		UI: BundleUIMapping{Legends: []BundleLegend{{BundleID: "b1", Tag: "B1"}, {BundleID: "b2", Tag: "B2"}}},
+	}
+}
+
+func TestReports(t *testing.T) {
+	// export writes the targets from index from on, keeping json and md short.
+	export := func(format string, from int) func(io.Writer, *ParseResult) error {
+		return func(w io.Writer, res *ParseResult) error { return WriteExport(w, res.Targets[from:], format) }
+	}
+	tests := []struct {
+		name  string
+		write func(io.Writer, *ParseResult) error
+		want  string
+	}{
+		{
+			name:  "tree",
+			write: func(w io.Writer, res *ParseResult) error { WriteTree(w, res); return nil },
+			want: `B1 b1  (3 targets)
+└─ net.inf
+   ├─ Windows 11 21H2 x64  [WINDOWS_v100_X64_CO_FULL]
+   │  ├─ PCI\VEN_8086&DEV_0001  Contoso | Contoso NIC
+   │  └─ PCI\VEN_8086&DEV_0002
+   └─ Windows 11 22H2 x64  [WINDOWS_v100_X64_NI_FULL]
+      └─ PCI\VEN_8086&DEV_0001  Contoso | Contoso NIC
+
+B2 b2  (1 targets)
+└─ wlan.inf
+   └─ Windows Server 2022 x64  [WINDOWS_v100_SERVER_X64_FE_FULL]
+      └─ PCI\VEN_8086&DEV_00F0  Wireless | AX
+
+`,
+		},
+		{
+			name:  "stats",
+			write: func(w io.Writer, res *ParseResult) error { WriteStats(w, res); return nil },
+			want: `Tag  Bundle                                  Targets  INFs  OSes   PnPs
+B1   b1                                            3     1     2      2
+B2   b2                                            1     1     1      1
+
+OS                           Code                                Targets   PnPs
+Windows 11 21H2 x64          WINDOWS_v100_X64_CO_FULL                  2      2
+Windows Server 2022 x64      WINDOWS_v100_SERVER_X64_FE_FULL           1      1
+Windows 11 22H2 x64          WINDOWS_v100_X64_NI_FULL                  1      1
+
+Total: 4 targets in 2 bundles
+`,
+		},
+		{
+			name:  "csv trims and keeps blank columns",
+			write: export("csv", 0),
+			want: `bundleTag,bundleId,infId,operatingSystemCode,os,pnpString,manufacturer,deviceDescription
+B1,b1,net.inf,WINDOWS_v100_X64_CO_FULL,Windows 11 21H2 x64,PCI\VEN_8086&DEV_0001,Contoso,Contoso NIC
+B1,b1,net.inf,WINDOWS_v100_X64_CO_FULL,Windows 11 21H2 x64,PCI\VEN_8086&DEV_0002,,
+B1,b1,net.inf,WINDOWS_v100_X64_NI_FULL,Windows 11 22H2 x64,PCI\VEN_8086&DEV_0001,Contoso,Contoso NIC
+B2,b2,wlan.inf,WINDOWS_v100_SERVER_X64_FE_FULL,Windows Server 2022 x64,PCI\VEN_8086&DEV_00F0,,Wireless | AX
+`,
+		},
+		{
+			name:  "json",
+			write: export("json", 3),
+			want: `[
+  {
+    "bundleTag": "B2",
+    "bundleId": "b2",
+    "infId": "wlan.inf",
+    "operatingSystemCode": "WINDOWS_v100_SERVER_X64_FE_FULL",
+    "os": "Windows Server 2022 x64",
+    "pnpString": "PCI\\VEN_8086&DEV_00F0",
+    "manufacturer": "",
+    "deviceDescription": "Wireless | AX"
+  }
+]
+`,
+		},
+		{
+			name:  "md escapes backslashes and pipes",
+			write: export("md", 3),
+			want: `| bundleTag | bundleId | infId | operatingSystemCode | os | pnpString | manufacturer | deviceDescription |
+| --- | --- | --- | --- | --- | --- | --- | --- |
+| B2 | b2 | wlan.inf | WINDOWS_v100_SERVER_X64_FE_FULL | Windows Server 2022 x64 | PCI\\VEN_8086&DEV_00F0 |  | Wireless \| AX |
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, reportResult()); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if err := WriteExport(io.Discard, nil, "xml"); err == nil {
+		t.Error("WriteExport(xml) succeeded")
+	}
+}
@@ -6,6 +6,7 @@ func ToTUILegends(legends []BundleLegend) []tui.Legend {
 	out := make([]tui.Legend, 0, len(legends))
 	for _, l := range legends {
 		out = append(out, tui.Legend{
+			Bundle:     l.BundleID,
 			Tag:        l.Tag,
 			Color:      tui.Color(l.Color),
 			ItemCount:  l.ItemCount,
@@ -0,0 +1,55 @@
+package drivermeta
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareDriverVersions compares INF DriverVer versions ("31.0.101.5186")
+// part by part, numerically where both parts are numbers. Missing parts
+// count as 0. Returns -1, 0 or 1.
+func CompareDriverVersions(a, b string) int {
+	pa := strings.Split(strings.TrimSpace(a), ".")
+	pb := strings.Split(strings.TrimSpace(b), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		x, y := "0", "0"
+		if i < len(pa) && pa[i] != "" {
+			x = pa[i]
+		}
+		if i < len(pb) && pb[i] != "" {
+			y = pb[i]
+		}
+		nx, errx := strconv.ParseUint(x, 10, 64)
+		ny, erry := strconv.ParseUint(y, 10, 64)
+		switch {
+		case errx == nil && erry == nil:
+			if nx != ny {
+				if nx < ny {
+					return -1
+				}
+				return 1
+			}
+		default:
+			if c := strings.Compare(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// DriverVersions maps lower(bundleId|infId) to the INF's driver version.
+func DriverVersions(targets []HardwareTarget) map[string]string {
+	out := map[string]string{}
+	for _, t := range targets {
+		if t.DriverVersion != "" {
+			out[DriverKey(t.BundleID, t.InfID)] = t.DriverVersion
+		}
+	}
+	return out
+}
+
+// DriverKey is the DriverVersions key of an INF within a bundle.
+func DriverKey(bundleID, infID string) string {
+	return strings.ToLower(bundleID + "|" + infID)
+}
@@ -0,0 +1,20 @@
+package drivermeta
+
+import "testing"
+
+func TestCompareDriverVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"31.0.101.5186", "31.0.101.5186", 0},
+		{"31.0.101.999", "31.0.101.5186", -1},
+		{"10.1", "10.0.9.9", 1},
+		{"1.0", "1.0.0.0", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareDriverVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareDriverVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
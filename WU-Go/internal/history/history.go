@@ -0,0 +1,167 @@
+// Package history keeps a record of the labels wu created: a timestamped
+// archive folder per run and an append-only JSONL ledger for searching.
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"WU/internal/format"
+	"WU/internal/support"
+)
+
+// LedgerFile is the ledger's name inside the history directory.
+const LedgerFile = "ledger.jsonl"
+
+// Entry is one ledger line: a label created or updated (or attempted) by a
+// run.
+type Entry struct {
+	Time           time.Time `json:"time"`
+	Operator       string    `json:"operator"`
+	Host           string    `json:"host,omitempty"`
+	Command        string    `json:"command"`
+	ProductID      string    `json:"productId"`
+	SubmissionID   string    `json:"submissionId"`
+	MetadataSHA256 string    `json:"metadataSha256,omitempty"`
+	Action         string    `json:"action"` // create | update
+	Status         string    `json:"status"` // ok | failed
+	Error          string    `json:"error,omitempty"`
+	LabelID        string    `json:"labelId,omitempty"`
+	LabelName      string    `json:"labelName"`
+	Destination    string    `json:"destination"`
+	PnpIDs         []string  `json:"pnpIds"`
+	CHIDs          []string  `json:"chids"`
+	Notes          string    `json:"notes,omitempty"` // targeting notes (--targeting-notes)
+	RunDir         string    `json:"runDir"`
+}
+
+// Run is the archive folder of one invocation.
+type Run struct {
+	Dir string
+}
+
+// StartRun creates <root>/<timestamp>-<command>/.
+func StartRun(root, command string, now time.Time) (*Run, error) {
+	name := now.Format("20060102-150405") + "-" + strings.ReplaceAll(command, " ", "-")
+	dir := filepath.Join(root, name)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		dir = filepath.Join(root, fmt.Sprintf("%s-%d", name, i))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Run{Dir: dir}, nil
+}
+
+// WriteJSON stores v as indented JSON in the run folder.
+func (r *Run) WriteJSON(name string, v any) error {
+	return os.WriteFile(filepath.Join(r.Dir, name), format.MustJSONIndent(v), 0644)
+}
+
+// Append adds an entry to the ledger, creating it if needed. Existing
+// lines are never rewritten.
+func Append(root string, e Entry) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(root, LedgerFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(format.MustJSON(e), '\n'))
+	return err
+}
+
+// Read returns all ledger entries, oldest first. A missing ledger is empty.
+func Read(root string) ([]Entry, error) {
+	f, err := os.Open(filepath.Join(root, LedgerFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, support.NewAPIError(fmt.Sprintf("%s 第 %d 行无法解析: %s", LedgerFile, line, err.Error()))
+		}
+		out = append(out, e)
+	}
+	return out, sc.Err()
+}
+
+// Query selects ledger entries; empty fields match everything. PnP matches
+// as a case-insensitive substring, CHID exactly (braces and case ignored).
+type Query struct {
+	ProductID string
+	CHID      string
+	PnP       string
+}
+
+// Filter returns the entries matching q.
+func Filter(entries []Entry, q Query) []Entry {
+	chid := normalizeCHID(q.CHID)
+	var out []Entry
+	for _, e := range entries {
+		if q.ProductID != "" && e.ProductID != q.ProductID {
+			continue
+		}
+		if chid != "" && !containsFunc(e.CHIDs, func(c string) bool { return normalizeCHID(c) == chid }) {
+			continue
+		}
+		if q.PnP != "" && !containsFunc(e.PnpIDs, func(p string) bool { return support.ContainsLower(p, strings.ToLower(q.PnP)) }) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func containsFunc(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeCHID(s string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(s), "{}"))
+}
+
+// Operator names who ran wu: the OS account, falling back to $USER/$USERNAME.
+func Operator() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return support.FirstNonEmpty(os.Getenv("USER"), os.Getenv("USERNAME"), "unknown")
+}
+
+// SHA256 is the hex digest of v's JSON encoding (map keys sorted), so the
+// same driverMetadata always hashes the same.
+func SHA256(v any) string {
+	sum := sha256.Sum256(format.MustJSON(v))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerAppendReadFilter(t *testing.T) {
+	root := t.TempDir()
+	entries := []Entry{
+		{ProductID: "1", LabelName: "a", PnpIDs: []string{"PCI\\VEN_8086&DEV_1"}, CHIDs: []string{"5b4e5bf1-a6ad-5e24-8c43-fd4b4f9b3fd3"}},
+		{ProductID: "2", LabelName: "b", PnpIDs: []string{"USB\\VID_1"}, CHIDs: []string{"11111111-2222-3333-4444-555555555555"}, Notes: "ODM pilot units only"},
+	}
+	for _, e := range entries {
+		if err := Append(root, e); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+
+	got, err := Read(root)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("Read() = %d entries, %v", len(got), err)
+	}
+	if got[0].Notes != "" || got[1].Notes != "ODM pilot units only" {
+		t.Errorf("notes = %q, %q", got[0].Notes, got[1].Notes)
+	}
+
+	tests := []struct {
+		q    Query
+		want string
+	}{
+		{Query{ProductID: "2"}, "b"},
+		{Query{CHID: "{5B4E5BF1-A6AD-5E24-8C43-FD4B4F9B3FD3}"}, "a"},
+		{Query{PnP: "ven_8086"}, "a"},
+	}
+	for _, tt := range tests {
+		found := Filter(got, tt.q)
+		if len(found) != 1 || found[0].LabelName != tt.want {
+			t.Errorf("Filter(%+v) = %+v, want %q", tt.q, found, tt.want)
+		}
+	}
+}
+
+func TestReadMissingLedger(t *testing.T) {
+	got, err := Read(t.TempDir())
+	if err != nil || got != nil {
+		t.Errorf("Read() = %v, %v; want empty", got, err)
+	}
+}
+
+func TestStartRunIsUnique(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	a, err := StartRun(root, "label post", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := StartRun(root, "label post", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Dir == b.Dir || filepath.Base(a.Dir) != "20260102-030405-label-post" {
+		t.Errorf("run dirs = %q, %q", a.Dir, b.Dir)
+	}
+	if err := a.WriteJSON("run.json", map[string]string{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(a.Dir, "run.json")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,99 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"WU/internal/format"
+)
+
+// PendingDir holds idempotency records inside the history directory.
+const PendingDir = "pending"
+
+// Pending is the idempotency record written just before a label is posted
+// and removed once the POST succeeds. A record left behind means the last
+// attempt failed or was cut off, and the label may or may not exist.
+type Pending struct {
+	Time          time.Time       `json:"time"`
+	Operator      string          `json:"operator"`
+	ProductID     string          `json:"productId"`
+	SubmissionID  string          `json:"submissionId"`
+	Name          string          `json:"name"`
+	PayloadSHA256 string          `json:"payloadSha256"`
+	Status        string          `json:"status"` // posting | failed
+	Error         string          `json:"error,omitempty"`
+	Body          json.RawMessage `json:"body"`
+}
+
+// Pending states.
+const (
+	PendingPosting = "posting"
+	PendingFailed  = "failed"
+)
+
+func pendingPath(root, productID, submissionID, name string) string {
+	sum := sha256.Sum256([]byte(productID + "|" + submissionID + "|" + strings.ToLower(strings.TrimSpace(name))))
+	return filepath.Join(root, PendingDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// SavePending writes (or replaces) the record for p's label.
+func SavePending(root string, p Pending) error {
+	path := pendingPath(root, p.ProductID, p.SubmissionID, p.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, format.MustJSONIndent(p), 0644)
+}
+
+// LoadPending returns the record for a label, or nil when there is none.
+func LoadPending(root, productID, submissionID, name string) (*Pending, error) {
+	b, err := os.ReadFile(pendingPath(root, productID, submissionID, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p Pending
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ClearPending removes the record for a label; a missing record is fine.
+func ClearPending(root, productID, submissionID, name string) error {
+	err := os.Remove(pendingPath(root, productID, submissionID, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ListPending returns all records, oldest first. Unreadable files are
+// skipped.
+func ListPending(root string) ([]Pending, error) {
+	files, err := filepath.Glob(filepath.Join(root, PendingDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []Pending
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var p Pending
+		if json.Unmarshal(b, &p) == nil {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
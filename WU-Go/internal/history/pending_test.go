@@ -0,0 +1,47 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPendingRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	p := Pending{
+		Time:         time.Now().UTC(),
+		ProductID:    "1",
+		SubmissionID: "2",
+		Name:         "OEM: Project",
+		Status:       PendingPosting,
+		Body:         []byte(`{"name":"OEM: Project"}`),
+	}
+	if err := SavePending(root, p); err != nil {
+		t.Fatalf("SavePending() error: %v", err)
+	}
+
+	got, err := LoadPending(root, "1", "2", " oem: project ")
+	if err != nil || got == nil || got.Status != PendingPosting {
+		t.Fatalf("LoadPending() = %+v, %v", got, err)
+	}
+	var body struct{ Name string }
+	if err := json.Unmarshal(got.Body, &body); err != nil || body.Name != "OEM: Project" {
+		t.Errorf("Body = %s, %v", got.Body, err)
+	}
+	if other, _ := LoadPending(root, "1", "3", "OEM: Project"); other != nil {
+		t.Errorf("record leaked to another submission: %+v", other)
+	}
+	if all, _ := ListPending(root); len(all) != 1 {
+		t.Errorf("ListPending() = %d records, want 1", len(all))
+	}
+
+	if err := ClearPending(root, "1", "2", "OEM: Project"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadPending(root, "1", "2", "OEM: Project"); got != nil {
+		t.Errorf("record still present after ClearPending")
+	}
+	if err := ClearPending(root, "1", "2", "OEM: Project"); err != nil {
+		t.Errorf("ClearPending() on missing record = %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Package labelindex keeps a local copy of the shipping labels of every
+// product and submission, so "which labels target this machine" can be
+// answered without crawling Dev Center each time.
+package labelindex
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"WU/internal/drivermeta"
+	"WU/internal/format"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+)
+
+// File is the index's name inside the history directory.
+const File = "label-index.json"
+
+// Index is the crawled state of the account.
+type Index struct {
+	Updated     time.Time              `json:"updated"`
+	Submissions map[string]*Submission `json:"submissions"` // key: Key(productID, submissionID)
+}
+
+// Submission is one submission and its labels. DriverVersions comes from
+// the driverMetadata and is only reloaded when Fingerprint changes.
+type Submission struct {
+	ProductID      string                `json:"productId"`
+	ProductName    string                `json:"productName,omitempty"`
+	SubmissionID   string                `json:"submissionId"`
+	SubmissionName string                `json:"submissionName,omitempty"`
+	Fingerprint    string                `json:"fingerprint"`
+	DriverVersions map[string]string     `json:"driverVersions,omitempty"` // drivermeta.DriverKey -> version
+	Labels         []shippinglabel.Label `json:"labels"`
+}
+
+// Key identifies a submission in the index.
+func Key(productID, submissionID string) string { return productID + "/" + submissionID }
+
+// Load reads the index at path; a missing file is an empty index.
+func Load(path string) (*Index, error) {
+	ix := &Index{Submissions: map[string]*Submission{}}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, ix); err != nil {
+		return nil, support.NewAPIError("标签索引无法解析: " + path + ": " + err.Error())
+	}
+	if ix.Submissions == nil {
+		ix.Submissions = map[string]*Submission{}
+	}
+	return ix, nil
+}
+
+// Save writes the index to path.
+func (ix *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, format.MustJSON(ix), 0644)
+}
+
+// Query selects labels by CHID and/or hardware ID; both empty matches
+// nothing. ProductID, when set, limits the search to one product.
+//
+// A hardware ID matches a label's PnP string when it is equal, when the
+// PnP string contains it, or when it is a more specific form of the PnP
+// string (PCI\VEN_8086&DEV_1234&SUBSYS_... matches PCI\VEN_8086&DEV_1234).
+type Query struct {
+	CHID      string
+	HWID      string
+	ProductID string
+}
+
+// Match is a label that targets the queried machine.
+type Match struct {
+	ProductID      string
+	ProductName    string
+	SubmissionID   string
+	SubmissionName string
+	Label          shippinglabel.Label
+	HardwareIDs    []shippinglabel.HardwareID // matched (all, for a CHID-only query)
+	DriverVersions []string                   // of the matched hardware IDs' INFs
+}
+
+// Find returns the matching labels, ordered by product, submission and
+// label name.
+func (ix *Index) Find(q Query) []Match {
+	chid := strings.ToLower(strings.Trim(strings.TrimSpace(q.CHID), "{}"))
+	hwid := strings.ToLower(strings.TrimSpace(q.HWID))
+	if chid == "" && hwid == "" {
+		return nil
+	}
+
+	var out []Match
+	for _, sub := range ix.Submissions {
+		if q.ProductID != "" && sub.ProductID != q.ProductID {
+			continue
+		}
+		for _, l := range sub.Labels {
+			if chid != "" && !targetsCHID(l, chid) {
+				continue
+			}
+			hids := l.Targeting.HardwareIDs
+			if hwid != "" {
+				hids = nil
+				for _, h := range l.Targeting.HardwareIDs {
+					if matchHWID(strings.ToLower(h.PnpString), hwid) {
+						hids = append(hids, h)
+					}
+				}
+				if len(hids) == 0 {
+					continue
+				}
+			}
+			out = append(out, Match{
+				ProductID:      sub.ProductID,
+				ProductName:    sub.ProductName,
+				SubmissionID:   sub.SubmissionID,
+				SubmissionName: sub.SubmissionName,
+				Label:          l,
+				HardwareIDs:    hids,
+				DriverVersions: sub.versions(hids),
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.ProductID != b.ProductID {
+			return a.ProductID < b.ProductID
+		}
+		if a.SubmissionID != b.SubmissionID {
+			return a.SubmissionID < b.SubmissionID
+		}
+		return a.Label.Name < b.Label.Name
+	})
+	return out
+}
+
+func targetsCHID(l shippinglabel.Label, chid string) bool {
+	for _, c := range l.Targeting.Chids {
+		if strings.ToLower(strings.Trim(c.Chid, "{}")) == chid {
+			return true
+		}
+	}
+	return false
+}
+
+func matchHWID(pnp, hwid string) bool {
+	return pnp == hwid || strings.Contains(pnp, hwid) || strings.HasPrefix(hwid, pnp+"&")
+}
+
+// versions lists the distinct driver versions of the hardware IDs' INFs.
+func (s *Submission) versions(hids []shippinglabel.HardwareID) []string {
+	var out []string
+	for _, h := range hids {
+		v := s.DriverVersions[drivermeta.DriverKey(h.BundleID, h.InfID)]
+		if v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return drivermeta.CompareDriverVersions(out[i], out[j]) < 0 })
+	return out
+}
@@ -0,0 +1,97 @@
+package labelindex
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"WU/internal/drivermeta"
+	"WU/internal/shippinglabel"
+)
+
+func testIndex() *Index {
+	label := func(id, name string, pnp []string, chids ...string) shippinglabel.Label {
+		l := shippinglabel.Label{ID: json.Number("1" + id)}
+		l.Name = name
+		for _, p := range pnp {
+			l.Targeting.HardwareIDs = append(l.Targeting.HardwareIDs, shippinglabel.HardwareID{BundleID: "b1", InfID: "net.inf", PnpString: p})
+		}
+		l.SetCHIDs(chids)
+		return l
+	}
+	return &Index{Submissions: map[string]*Submission{
+		Key("p1", "s1"): {
+			ProductID: "p1", SubmissionID: "s1",
+			DriverVersions: map[string]string{drivermeta.DriverKey("b1", "net.inf"): "1.2.3.4"},
+			Labels: []shippinglabel.Label{
+				label("1", "X1", []string{`PCI\VEN_8086&DEV_1234`}, "6de5d951-d755-576b-bd09-c5cf66b27234"),
+				label("2", "T14", []string{`PCI\VEN_8086&DEV_9999`}, "e093d715-70f7-51f4-b6c8-b4a7e31def85"),
+			},
+		},
+		Key("p2", "s9"): {
+			ProductID: "p2", SubmissionID: "s9",
+			Labels: []shippinglabel.Label{
+				label("3", "X1 old", []string{`PCI\VEN_8086&DEV_1234&SUBSYS_0001`}, "6de5d951-d755-576b-bd09-c5cf66b27234"),
+			},
+		},
+	}}
+}
+
+func TestFindByCHID(t *testing.T) {
+	got := testIndex().Find(Query{CHID: "{6DE5D951-D755-576B-BD09-C5CF66B27234}"})
+	if len(got) != 2 || got[0].Label.Name != "X1" || got[1].Label.Name != "X1 old" {
+		t.Fatalf("got %+v", got)
+	}
+	if len(got[0].DriverVersions) != 1 || got[0].DriverVersions[0] != "1.2.3.4" {
+		t.Fatalf("versions = %v", got[0].DriverVersions)
+	}
+	if len(got[1].DriverVersions) != 0 {
+		t.Fatalf("unexpected versions %v", got[1].DriverVersions)
+	}
+}
+
+func TestFindByHWID(t *testing.T) {
+	ix := testIndex()
+	// A device's full hardware ID matches the more generic label PnP string.
+	got := ix.Find(Query{HWID: `pci\ven_8086&dev_1234&subsys_0002&rev_01`})
+	if len(got) != 1 || got[0].Label.Name != "X1" {
+		t.Fatalf("specific: %+v", got)
+	}
+	// A partial ID matches every label containing it.
+	if got := ix.Find(Query{HWID: "DEV_1234"}); len(got) != 2 {
+		t.Fatalf("partial: %+v", got)
+	}
+	if got := ix.Find(Query{HWID: "DEV_1234", ProductID: "p2"}); len(got) != 1 {
+		t.Fatalf("product filter: %+v", got)
+	}
+	if got := ix.Find(Query{HWID: "DEV_1234", CHID: "e093d715-70f7-51f4-b6c8-b4a7e31def85"}); len(got) != 0 {
+		t.Fatalf("both: %+v", got)
+	}
+	if got := ix.Find(Query{}); got != nil {
+		t.Fatalf("empty query: %+v", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+	ix, err := Load(path)
+	if err != nil || len(ix.Submissions) != 0 {
+		t.Fatalf("missing index: %+v, %v", ix, err)
+	}
+	ix = testIndex()
+	ix.Updated = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if err := ix.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !back.Updated.Equal(ix.Updated) || len(back.Submissions) != 2 {
+		t.Fatalf("round trip: %+v", back)
+	}
+	if id := back.Submissions[Key("p1", "s1")].Labels[0].ID; id != "11" {
+		t.Fatalf("label id = %q", id)
+	}
+}
@@ -0,0 +1,114 @@
+package labelspec
+
+import (
+	"fmt"
+	"strings"
+
+	"WU/internal/cli"
+	"WU/internal/drivermeta"
+	"WU/internal/shippinglabel"
+	"WU/internal/support"
+	"WU/internal/validate"
+)
+
+// Build turns the spec into one request per label. Targets are selected
+// from the submission's driverMetadata; base carries the command-line
+// options the spec overrides. Requests are not validated.
+func (s *Spec) Build(base *cli.CLIOptions, targets []drivermeta.HardwareTarget) ([]*shippinglabel.Request, error) {
+	if len(s.Labels) == 0 {
+		return nil, support.NewAPIError("label spec 中没有 labels")
+	}
+
+	var problems []string
+	var out []*shippinglabel.Request
+	seen := map[string]bool{}
+	for i := range s.Labels {
+		l := &s.Labels[i]
+		key := strings.ToLower(strings.TrimSpace(l.Name))
+		if key == "" {
+			problems = append(problems, fmt.Sprintf("labels[%d]: name 不能为空", i))
+			continue
+		}
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf("labels[%d]: 名称 %q 重复", i, l.Name))
+			continue
+		}
+		seen[key] = true
+
+		req, err := l.build(base, &s.Defaults, targets)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("labels[%d] %q: %s", i, l.Name, err.Error()))
+			continue
+		}
+		out = append(out, req)
+	}
+	if len(problems) > 0 {
+		return nil, support.NewAPIError("label spec 有误:\n  - " + strings.Join(problems, "\n  - "))
+	}
+	return out, nil
+}
+
+func (l *LabelSpec) build(base *cli.CLIOptions, defaults *Options, targets []drivermeta.HardwareTarget) (*shippinglabel.Request, error) {
+	opt := *base
+	defaults.apply(&opt)
+	l.Options.apply(&opt)
+
+	selected, err := l.Targets.Select(targets)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, support.NewAPIError("targets 没有匹配任何 hardware ID")
+	}
+
+	var chids []string
+	if len(opt.Chids) > 0 {
+		if chids, err = validate.NormalizeCHIDsRequired(opt.Chids); err != nil {
+			return nil, err
+		}
+	}
+	return shippinglabel.BuildPayload(&opt, strings.TrimSpace(l.Name), selected, chids), nil
+}
+
+// Select returns the targets matching every rule that is set.
+func (t *Targets) Select(targets []drivermeta.HardwareTarget) ([]drivermeta.HardwareTarget, error) {
+	if !t.All && support.IsBlank(t.OSFilter) && len(t.Bundles) == 0 && len(t.Infs) == 0 && len(t.PnP) == 0 {
+		return nil, support.NewAPIError("targets 为空（用 all: true 选择全部）")
+	}
+	filter, err := drivermeta.ParseOSFilter(t.OSFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []drivermeta.HardwareTarget
+	for _, h := range drivermeta.FilterTargetsByOS(targets, filter) {
+		if len(t.Bundles) > 0 && !matchAny(t.Bundles, h.BundleID, h.BundleTag) {
+			continue
+		}
+		if len(t.Infs) > 0 && !matchAny(t.Infs, h.InfID) {
+			continue
+		}
+		if len(t.PnP) > 0 && !matchAny(t.PnP, h.PnpID) {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// matchAny reports whether any pattern equals one of values (case
+// insensitive); a pattern ending in "*" matches by prefix.
+func matchAny(patterns []string, values ...string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		prefix := strings.HasSuffix(p, "*")
+		p = strings.TrimSuffix(p, "*")
+		for _, v := range values {
+			v = strings.ToLower(v)
+			if v == p || (prefix && strings.HasPrefix(v, p)) {
+				return true
+			}
+		}
+	}
+	return false
+}
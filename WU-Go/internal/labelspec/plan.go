@@ -0,0 +1,193 @@
+package labelspec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"WU/internal/shippinglabel"
+)
+
+// Action is what apply does for one label.
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+)
+
+// Step is the planned action for one label of the spec. For updates, Body is
+// the PATCH body and Changes lists what differs from the server.
+type Step struct {
+	Action   Action
+	Body     *shippinglabel.Request
+	Existing *shippinglabel.Label
+	Changes  []string
+}
+
+// Plan is the result of comparing a spec with the server. Unmanaged lists
+// server labels the spec does not mention; they are left alone.
+type Plan struct {
+	Steps     []Step
+	Unmanaged []shippinglabel.Label
+}
+
+// Pending counts the steps that change something.
+func (p *Plan) Pending() int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Action != ActionUnchanged {
+			n++
+		}
+	}
+	return n
+}
+
+// Compare matches wanted requests to existing labels by name.
+func Compare(want []*shippinglabel.Request, existing []shippinglabel.Label) *Plan {
+	byName := map[string]int{}
+	for i, l := range existing {
+		byName[strings.ToLower(strings.TrimSpace(l.Name))] = i
+	}
+
+	p := &Plan{}
+	used := map[int]bool{}
+	for _, req := range want {
+		i, ok := byName[strings.ToLower(strings.TrimSpace(req.Name))]
+		if !ok {
+			p.Steps = append(p.Steps, Step{Action: ActionCreate, Body: req})
+			continue
+		}
+		used[i] = true
+		have := &existing[i]
+		step := Step{Action: ActionUnchanged, Body: req, Existing: have, Changes: Changes(req, have)}
+		if len(step.Changes) > 0 {
+			step.Action = ActionUpdate
+			step.Body = UpdateBody(req, have)
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	for i, l := range existing {
+		if !used[i] {
+			p.Unmanaged = append(p.Unmanaged, l)
+		}
+	}
+	return p
+}
+
+// Changes lists the differences between a wanted request and a server
+// label, one human-readable line each.
+func Changes(want *shippinglabel.Request, have *shippinglabel.Label) []string {
+	var out []string
+	diff := func(field string, a, b any) {
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			out = append(out, fmt.Sprintf("%s: %v -> %v", field, b, a))
+		}
+	}
+
+	diff("destination", want.Destination, have.Destination)
+	if added, removed := setDiff(hardwareKeys(want.Targeting.HardwareIDs), hardwareKeys(have.Targeting.HardwareIDs)); added+removed > 0 {
+		out = append(out, fmt.Sprintf("hardwareIds: +%d -%d", added, removed))
+	}
+	if added, removed := setDiff(chidKeys(want.Targeting.Chids), chidKeys(have.Targeting.Chids)); added+removed > 0 {
+		out = append(out, fmt.Sprintf("chids: +%d -%d", added, removed))
+	}
+	diff("inServicePublishInfo", inService(want.Targeting.InServicePublishInfo), inService(have.Targeting.InServicePublishInfo))
+	diff("restrictedToAudiences", sortedLower(want.Targeting.RestrictedToAudiences), sortedLower(have.Targeting.RestrictedToAudiences))
+
+	if w, h := want.PublishingSpecifications, have.PublishingSpecifications; w != nil && h != nil {
+		if w.GoLiveDate != "" { // immediate labels get a date once published
+			diff("goLiveDate", w.GoLiveDate, h.GoLiveDate)
+		}
+		diff("visibleToAccounts", w.VisibleToAccounts, h.VisibleToAccounts)
+		diff("isAutoInstallDuringOSUpgrade", w.IsAutoInstallDuringOSUpgrade, h.IsAutoInstallDuringOSUpgrade)
+		diff("isAutoInstallOnApplicableSystems", w.IsAutoInstallOnApplicableSystems, h.IsAutoInstallOnApplicableSystems)
+		diff("manualAcquisition", w.ManualAcquisition, h.ManualAcquisition)
+		diff("isDisclosureRestricted", w.IsDisclosureRestricted, h.IsDisclosureRestricted)
+		diff("publishToWindows10s", w.PublishToWindows10s, h.PublishToWindows10s)
+	}
+	if w, h := want.RecipientSpecifications, have.RecipientSpecifications; w != nil && h != nil {
+		diff("receiverPublisherId", w.ReceiverPublisherID, h.ReceiverPublisherID)
+		diff("enforceChidTargeting", w.EnforceChidTargeting, h.EnforceChidTargeting)
+	}
+	return out
+}
+
+// UpdateBody is want with CHID states fitted to the server: CHIDs already
+// on the label keep their state and CHIDs dropped from the spec are sent as
+// pendingRemove.
+func UpdateBody(want *shippinglabel.Request, have *shippinglabel.Label) *shippinglabel.Request {
+	body := *want
+	state := map[string]string{}
+	for _, c := range have.Targeting.Chids {
+		state[strings.ToLower(c.Chid)] = c.DistributionState
+	}
+	kept := map[string]bool{}
+	body.Targeting.Chids = nil
+	for _, c := range want.Targeting.Chids {
+		key := strings.ToLower(c.Chid)
+		kept[key] = true
+		if s, ok := state[key]; ok && !isRemoved(s) {
+			c.DistributionState = s
+		}
+		body.Targeting.Chids = append(body.Targeting.Chids, c)
+	}
+	for _, c := range have.Targeting.Chids {
+		if !kept[strings.ToLower(c.Chid)] && !isRemoved(c.DistributionState) {
+			body.Targeting.Chids = append(body.Targeting.Chids, shippinglabel.CHID{Chid: c.Chid, DistributionState: shippinglabel.DistributionPendingRemove})
+		}
+	}
+	return &body
+}
+
+func isRemoved(state string) bool { return strings.Contains(strings.ToLower(state), "remove") }
+
+func hardwareKeys(ids []shippinglabel.HardwareID) map[string]bool {
+	out := map[string]bool{}
+	for _, h := range ids {
+		out[strings.ToLower(h.BundleID+"|"+h.InfID+"|"+h.OperatingSystemCode+"|"+h.PnpString)] = true
+	}
+	return out
+}
+
+// chidKeys ignores CHIDs that are on their way out.
+func chidKeys(chids []shippinglabel.CHID) map[string]bool {
+	out := map[string]bool{}
+	for _, c := range chids {
+		if !isRemoved(c.DistributionState) {
+			out[strings.ToLower(c.Chid)] = true
+		}
+	}
+	return out
+}
+
+func setDiff(want, have map[string]bool) (added, removed int) {
+	for k := range want {
+		if !have[k] {
+			added++
+		}
+	}
+	for k := range have {
+		if !want[k] {
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func inService(isp *shippinglabel.InServicePublishInfo) string {
+	if isp == nil {
+		return "-"
+	}
+	return isp.Flooring + ".." + isp.Ceiling
+}
+
+func sortedLower(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, strings.ToLower(v))
+	}
+	sort.Strings(out)
+	return out
+}
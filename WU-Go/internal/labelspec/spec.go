@@ -0,0 +1,145 @@
+// Package labelspec reads a declarative description of the shipping labels
+// a submission should have and plans the changes needed to get there.
+package labelspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"WU/internal/cli"
+	"WU/internal/support"
+)
+
+// Spec is the file given to wu plan / wu apply. Defaults apply to every
+// label; a label's own options override them.
+type Spec struct {
+	ProductID    string      `json:"productId" yaml:"productId"`
+	SubmissionID string      `json:"submissionId" yaml:"submissionId"`
+	Defaults     Options     `json:"defaults" yaml:"defaults"`
+	Labels       []LabelSpec `json:"labels" yaml:"labels"`
+}
+
+// LabelSpec describes one label. Name identifies it on the server.
+type LabelSpec struct {
+	Name    string  `json:"name" yaml:"name"`
+	Targets Targets `json:"targets" yaml:"targets"`
+	Options `yaml:",inline"`
+}
+
+// Targets selects hardware IDs from the submission's driverMetadata. Each
+// list matches any of its entries; the lists and osFilter must all match.
+// PnP entries ending in "*" match by prefix.
+type Targets struct {
+	All      bool     `json:"all,omitempty" yaml:"all,omitempty"`
+	OSFilter string   `json:"osFilter,omitempty" yaml:"osFilter,omitempty"`
+	Bundles  []string `json:"bundles,omitempty" yaml:"bundles,omitempty"` // bundle IDs or tags (B1)
+	Infs     []string `json:"infs,omitempty" yaml:"infs,omitempty"`
+	PnP      []string `json:"pnp,omitempty" yaml:"pnp,omitempty"`
+}
+
+// Options mirror the label flags of the interactive workflow. Unset (nil)
+// fields keep the value from the command line or the defaults.
+type Options struct {
+	Destination          *string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	ReceiverPublisherID  *string `json:"receiverPublisherId,omitempty" yaml:"receiverPublisherId,omitempty"`
+	EnforceChidTargeting *bool   `json:"enforceChidTargeting,omitempty" yaml:"enforceChidTargeting,omitempty"`
+
+	GoLiveDate                     *string `json:"goLiveDate,omitempty" yaml:"goLiveDate,omitempty"` // "" = immediate
+	VisibleToAccounts              []int   `json:"visibleToAccounts,omitempty" yaml:"visibleToAccounts,omitempty"`
+	AutoInstallDuringOSUpgrade     *bool   `json:"isAutoInstallDuringOSUpgrade,omitempty" yaml:"isAutoInstallDuringOSUpgrade,omitempty"`
+	AutoInstallOnApplicableSystems *bool   `json:"isAutoInstallOnApplicableSystems,omitempty" yaml:"isAutoInstallOnApplicableSystems,omitempty"`
+	IsDisclosureRestricted         *bool   `json:"isDisclosureRestricted,omitempty" yaml:"isDisclosureRestricted,omitempty"`
+	PublishToWindows10s            *bool   `json:"publishToWindows10s,omitempty" yaml:"publishToWindows10s,omitempty"`
+
+	MsContact               *string  `json:"microsoftContact,omitempty" yaml:"microsoftContact,omitempty"`
+	ValidationsPerformed    *string  `json:"validationsPerformed,omitempty" yaml:"validationsPerformed,omitempty"`
+	AffectedOems            []string `json:"affectedOems,omitempty" yaml:"affectedOems,omitempty"`
+	IsRebootRequired        *bool    `json:"isRebootRequired,omitempty" yaml:"isRebootRequired,omitempty"`
+	IsCoEngineered          *bool    `json:"isCoEngineered,omitempty" yaml:"isCoEngineered,omitempty"`
+	IsForUnreleasedHardware *bool    `json:"isForUnreleasedHardware,omitempty" yaml:"isForUnreleasedHardware,omitempty"`
+	HasUiSoftware           *bool    `json:"hasUiSoftware,omitempty" yaml:"hasUiSoftware,omitempty"`
+	BusinessJustification   *string  `json:"businessJustification,omitempty" yaml:"businessJustification,omitempty"`
+
+	FloorOS   *string  `json:"floorOS,omitempty" yaml:"floorOS,omitempty"`
+	CeilingOS *string  `json:"ceilingOS,omitempty" yaml:"ceilingOS,omitempty"`
+	Audiences []string `json:"audiences,omitempty" yaml:"audiences,omitempty"`
+	Chids     []string `json:"chids,omitempty" yaml:"chids,omitempty"`
+}
+
+// Load reads a spec; .json files are JSON, anything else YAML. Unknown
+// fields are rejected so typos do not silently drop a setting.
+func Load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&spec)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		err = dec.Decode(&spec)
+	}
+	if err != nil {
+		return nil, support.NewAPIError("label spec 解析失败 (" + path + "): " + err.Error())
+	}
+	return &spec, nil
+}
+
+// apply overlays the set fields on opt.
+func (o *Options) apply(opt *cli.CLIOptions) {
+	setString := func(dst *string, v *string) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	setBool := func(dst *bool, v *bool) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+
+	setString(&opt.Destination, o.Destination)
+	setString(&opt.ReceiverPublisherID, o.ReceiverPublisherID)
+	setBool(&opt.EnforceChidTargeting, o.EnforceChidTargeting)
+
+	if o.GoLiveDate != nil {
+		opt.GoLiveDate = *o.GoLiveDate
+		opt.GoLiveImmediate = support.IsBlank(*o.GoLiveDate)
+	}
+	if o.VisibleToAccounts != nil {
+		opt.VisibleToAccounts = append([]int{}, o.VisibleToAccounts...)
+	}
+	setBool(&opt.AutoInstallDuringOSUpgrade, o.AutoInstallDuringOSUpgrade)
+	setBool(&opt.AutoInstallOnApplicableSystems, o.AutoInstallOnApplicableSystems)
+	setBool(&opt.IsDisclosureRestricted, o.IsDisclosureRestricted)
+	setBool(&opt.PublishToWindows10s, o.PublishToWindows10s)
+
+	setString(&opt.MsContact, o.MsContact)
+	setString(&opt.ValidationsPerformed, o.ValidationsPerformed)
+	if o.AffectedOems != nil {
+		opt.AffectedOems = append([]string{}, o.AffectedOems...)
+	}
+	setBool(&opt.IsRebootRequired, o.IsRebootRequired)
+	setBool(&opt.IsCoEngineered, o.IsCoEngineered)
+	setBool(&opt.IsForUnreleasedHardware, o.IsForUnreleasedHardware)
+	setBool(&opt.HasUiSoftware, o.HasUiSoftware)
+	setString(&opt.BusinessJustification, o.BusinessJustification)
+
+	setString(&opt.FloorOS, o.FloorOS)
+	setString(&opt.CeilingOS, o.CeilingOS)
+	if o.Audiences != nil {
+		opt.Audiences = append([]string{}, o.Audiences...)
+	}
+	if o.Chids != nil {
+		opt.Chids = append([]string{}, o.Chids...)
+	}
+}
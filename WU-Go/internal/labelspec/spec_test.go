@@ -0,0 +1,112 @@
+package labelspec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"WU/internal/cli"
+	"WU/internal/drivermeta"
+	"WU/internal/shippinglabel"
+)
+
+const testSpec = `
+productId: "1"
+submissionId: "2"
+defaults:
+  microsoftContact: someone@microsoft.com
+  chids: [5b4e5bf1-a6ad-5e24-8c43-fd4b4f9b3fd3]
+labels:
+  - name: "OEM: W11"
+    targets:
+      osFilter: windows=11
+  - name: "OEM: RS5 net"
+    targets:
+      pnp: ["PCI\\VEN_8086*"]
+      osFilter: release=RS5
+    floorOS: "1809"
+`
+
+func testTargets() []drivermeta.HardwareTarget {
+	return []drivermeta.HardwareTarget{
+		{BundleID: "b1", BundleTag: "B1", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_NI_FULL", PnpID: "PCI\\VEN_8086&DEV_1"},
+		{BundleID: "b1", BundleTag: "B1", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_RS5_FULL", PnpID: "PCI\\VEN_8086&DEV_1"},
+		{BundleID: "b1", BundleTag: "B1", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_RS5_FULL", PnpID: "USB\\VID_1"},
+	}
+}
+
+func loadTestSpec(t *testing.T) *Spec {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "labels.yaml")
+	if err := os.WriteFile(path, []byte(testSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	return spec
+}
+
+func TestBuild(t *testing.T) {
+	base, _ := cli.ParseCLIOptions(nil)
+	reqs, err := loadTestSpec(t).Build(base, testTargets())
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	if n := len(reqs[0].Targeting.HardwareIDs); n != 1 {
+		t.Errorf("W11 label has %d hardwareIds, want 1", n)
+	}
+	r := reqs[1]
+	if n := len(r.Targeting.HardwareIDs); n != 1 || r.Targeting.HardwareIDs[0].PnpString != "PCI\\VEN_8086&DEV_1" {
+		t.Errorf("RS5 label hardwareIds = %+v", r.Targeting.HardwareIDs)
+	}
+	if isp := r.Targeting.InServicePublishInfo; isp == nil || isp.Flooring != "RS5" {
+		t.Errorf("inServicePublishInfo = %+v, want floor RS5", isp)
+	}
+	if len(r.Targeting.Chids) != 1 || r.PublishingSpecifications.AdditionalInfoForMsApproval.MicrosoftContact != "someone@microsoft.com" {
+		t.Errorf("defaults not applied: %+v", r)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.yaml")
+	os.WriteFile(path, []byte("labels:\n  - name: x\n    chidz: []\n"), 0644)
+	if _, err := Load(path); err == nil {
+		t.Errorf("expected error for unknown field")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base, _ := cli.ParseCLIOptions(nil)
+	reqs, err := loadTestSpec(t).Build(base, testTargets())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	same := shippinglabel.Label{ID: "10", Request: *reqs[0]}
+	changed := shippinglabel.Label{ID: "11", Request: *reqs[1]}
+	changed.SetCHIDs([]string{"11111111-2222-3333-4444-555555555555"})
+	other := shippinglabel.Label{ID: "12"}
+	other.Name = "manual"
+
+	p := Compare(reqs, []shippinglabel.Label{same, changed, other})
+	if p.Steps[0].Action != ActionUnchanged || p.Steps[1].Action != ActionUpdate {
+		t.Fatalf("actions = %s, %s", p.Steps[0].Action, p.Steps[1].Action)
+	}
+	chids := p.Steps[1].Body.Targeting.Chids
+	if len(chids) != 2 || chids[1].DistributionState != shippinglabel.DistributionPendingRemove {
+		t.Errorf("update chids = %+v, want the old CHID as pendingRemove", chids)
+	}
+	if len(p.Unmanaged) != 1 || p.Pending() != 1 {
+		t.Errorf("unmanaged = %d, pending = %d", len(p.Unmanaged), p.Pending())
+	}
+
+	p = Compare(reqs[:1], nil)
+	if p.Steps[0].Action != ActionCreate {
+		t.Errorf("action = %s, want create", p.Steps[0].Action)
+	}
+}
@@ -0,0 +1,86 @@
+package shippinglabel
+
+import "strings"
+
+// ExistingLabel is a label already on the product and the submission it
+// belongs to.
+type ExistingLabel struct {
+	SubmissionID string
+	Label
+}
+
+// Overlap is a PnP ID + CHID pair targeted by both a new and an existing
+// label, with the hardware ID entry on each side.
+type Overlap struct {
+	PnpID string
+	CHID  string
+	New   HardwareID
+	Old   HardwareID
+}
+
+// Conflict lists the overlaps between one new label and one existing label.
+type Conflict struct {
+	New      *Request
+	Existing ExistingLabel
+	Overlaps []Overlap
+}
+
+// FindConflicts reports existing Windows Update labels that target a PnP ID
+// + CHID pair also targeted by one of reqs. Labels without CHIDs are not
+// compared: they do not compete on the same devices.
+func FindConflicts(reqs []*Request, existing []ExistingLabel) []Conflict {
+	var out []Conflict
+	for _, req := range reqs {
+		if req.Destination == DestinationAnotherPartner {
+			continue
+		}
+		newPnP := firstByPnP(req.Targeting.HardwareIDs)
+		newCHIDs := chidSet(req.Targeting.Chids)
+		for _, ex := range existing {
+			if ex.Destination == DestinationAnotherPartner {
+				continue
+			}
+			oldPnP := firstByPnP(ex.Targeting.HardwareIDs)
+			var overlaps []Overlap
+			for _, c := range ex.Targeting.Chids {
+				chid := strings.ToLower(c.Chid)
+				if !newCHIDs[chid] {
+					continue
+				}
+				for _, h := range req.Targeting.HardwareIDs {
+					key := strings.ToLower(h.PnpString)
+					old, ok := oldPnP[key]
+					if !ok || newPnP[key] != h {
+						continue
+					}
+					overlaps = append(overlaps, Overlap{PnpID: h.PnpString, CHID: chid, New: h, Old: old})
+				}
+			}
+			if len(overlaps) > 0 {
+				out = append(out, Conflict{New: req, Existing: ex, Overlaps: overlaps})
+			}
+		}
+	}
+	return out
+}
+
+// firstByPnP indexes hardware IDs by PnP ID; a PnP listed for several OSes
+// is represented by its first entry.
+func firstByPnP(ids []HardwareID) map[string]HardwareID {
+	out := map[string]HardwareID{}
+	for _, h := range ids {
+		key := strings.ToLower(h.PnpString)
+		if _, ok := out[key]; !ok {
+			out[key] = h
+		}
+	}
+	return out
+}
+
+func chidSet(chids []CHID) map[string]bool {
+	out := map[string]bool{}
+	for _, c := range chids {
+		out[strings.ToLower(c.Chid)] = true
+	}
+	return out
+}
@@ -0,0 +1,22 @@
+package shippinglabel
+
+import "testing"
+
+func TestFindConflicts(t *testing.T) {
+	req := validRequest()
+
+	same := ExistingLabel{SubmissionID: "2", Label: Label{Request: *validRequest()}}
+	same.Name = "older"
+	otherCHID := ExistingLabel{SubmissionID: "3", Label: Label{Request: *validRequest()}}
+	otherCHID.SetCHIDs([]string{"11111111-2222-3333-4444-555555555555"})
+	partner := ExistingLabel{SubmissionID: "4", Label: Label{Request: *validRequest()}}
+	partner.Destination = DestinationAnotherPartner
+
+	got := FindConflicts([]*Request{req}, []ExistingLabel{same, otherCHID, partner})
+	if len(got) != 1 || got[0].Existing.Name != "older" {
+		t.Fatalf("FindConflicts() = %+v, want one conflict with \"older\"", got)
+	}
+	if o := got[0].Overlaps; len(o) != 1 || o[0].PnpID != "PCI\\VEN_1" {
+		t.Errorf("Overlaps = %+v", o)
+	}
+}
@@ -1,69 +1,94 @@
 package shippinglabel
 
 import (
+	"strings"
+
 	"WU/internal/cli"
 	"WU/internal/drivermeta"
 	"WU/internal/support"
 )
 
-func BuildPayload(opt *cli.CLIOptions, name string, targets []drivermeta.HardwareTarget, chids []string) (map[string]any, error) {
-	if len(chids) == 0 {
-		return nil, support.NewAPIError("CHIDs 必须至少提供 1 个（必填）。")
+// BuildPayload assembles the request from CLI options and the selection.
+// It does not validate; call Validate before writing or posting.
+func BuildPayload(opt *cli.CLIOptions, name string, targets []drivermeta.HardwareTarget, chids []string) *Request {
+	goLive := ""
+	if !opt.GoLiveImmediate {
+		goLive = support.Or(opt.GoLiveDate, "")
+		if norm, err := NormalizeGoLiveDate(goLive); err == nil {
+			goLive = norm
+		}
 	}
 
-	publishing := map[string]any{
-		"goLiveDate": func() string {
-			if opt.GoLiveImmediate {
-				return ""
-			}
-			return support.Or(opt.GoLiveDate, "")
-		}(),
-		"visibleToAccounts":             opt.VisibleToAccounts,
-		"isAutoInstallDuringOSUpgrade":  opt.AutoInstallDuringOSUpgrade,
-		"isAutoInstallOnApplicableSystems": opt.AutoInstallOnApplicableSystems,
-		"manualAcquisition":             (!opt.AutoInstallDuringOSUpgrade && !opt.AutoInstallOnApplicableSystems),
-		"isDisclosureRestricted":        opt.IsDisclosureRestricted,
-		"publishToWindows10s":           opt.PublishToWindows10s,
+	publishing := &PublishingSpecifications{
+		GoLiveDate:                       goLive,
+		VisibleToAccounts:                append([]int{}, opt.VisibleToAccounts...),
+		IsAutoInstallDuringOSUpgrade:     opt.AutoInstallDuringOSUpgrade,
+		IsAutoInstallOnApplicableSystems: opt.AutoInstallOnApplicableSystems,
+		ManualAcquisition:                !opt.AutoInstallDuringOSUpgrade && !opt.AutoInstallOnApplicableSystems,
+		IsDisclosureRestricted:           opt.IsDisclosureRestricted,
+		PublishToWindows10s:              opt.PublishToWindows10s,
+		Scheduled:                        !opt.GoLiveImmediate,
 	}
 
-	if opt.AutoInstallDuringOSUpgrade || opt.AutoInstallOnApplicableSystems {
-		publishing["additionalInfoForMsApproval"] = map[string]any{
-			"microsoftContact":        opt.MsContact,
-			"validationsPerformed":    opt.ValidationsPerformed,
-			"affectedOems":            opt.AffectedOems,
-			"isRebootRequired":        opt.IsRebootRequired,
-			"isCoEngineered":          opt.IsCoEngineered,
-			"isForUnreleasedHardware": opt.IsForUnreleasedHardware,
-			"hasUiSoftware":           opt.HasUiSoftware,
-			"businessJustification":   opt.BusinessJustification,
+	if publishing.AutoInstall() {
+		publishing.AdditionalInfoForMsApproval = &AdditionalInfoForMsApproval{
+			MicrosoftContact:        opt.MsContact,
+			ValidationsPerformed:    opt.ValidationsPerformed,
+			AffectedOems:            append([]string{}, opt.AffectedOems...),
+			IsRebootRequired:        opt.IsRebootRequired,
+			IsCoEngineered:          opt.IsCoEngineered,
+			IsForUnreleasedHardware: opt.IsForUnreleasedHardware,
+			HasUiSoftware:           opt.HasUiSoftware,
+			BusinessJustification:   opt.BusinessJustification,
 		}
 	}
 
-	hwids := make([]any, 0, len(targets))
-	for _, t := range targets {
-		hwids = append(hwids, map[string]any{
-			"bundleId":            t.BundleID,
-			"infId":               t.InfID,
-			"operatingSystemCode": t.OSCode,
-			"pnpString":           t.PnpID,
-		})
+	req := &Request{
+		Name:        name,
+		Destination: opt.Destination,
+	}
+	if opt.Destination == DestinationAnotherPartner {
+		req.RecipientSpecifications = &RecipientSpecifications{
+			ReceiverPublisherID:  strings.TrimSpace(opt.ReceiverPublisherID),
+			EnforceChidTargeting: opt.EnforceChidTargeting,
+		}
+	} else {
+		req.PublishingSpecifications = publishing
+	}
+	req.Targeting.HardwareIDs = HardwareIDs(targets)
+	req.SetCHIDs(chids)
+	req.Targeting.InServicePublishInfo = InServiceRange(opt.FloorOS, opt.CeilingOS)
+	req.SetAudiences(opt.Audiences)
+	return req
+}
+
+// InServiceRange builds inServicePublishInfo from --floor-os/--ceiling-os.
+// Versions such as 22H2 become release tokens; unknown values are kept as
+// given so Validate can report them. Returns nil when both are empty.
+func InServiceRange(floor, ceiling string) *InServicePublishInfo {
+	if support.IsBlank(floor) && support.IsBlank(ceiling) {
+		return nil
+	}
+	norm := func(v string) string {
+		v = strings.TrimSpace(v)
+		if t, ok := drivermeta.ResolveReleaseToken(v); ok {
+			return t
+		}
+		return v
 	}
+	return &InServicePublishInfo{Flooring: norm(floor), Ceiling: norm(ceiling)}
+}
 
-	chidArr := make([]any, 0, len(chids))
-	for _, c := range chids {
-		chidArr = append(chidArr, map[string]any{
-			"chid":              c,
-			"distributionState": "pendingAdd",
+// HardwareIDs converts selected targets into targeting entries.
+func HardwareIDs(targets []drivermeta.HardwareTarget) []HardwareID {
+	out := make([]HardwareID, 0, len(targets))
+	for _, t := range targets {
+		out = append(out, HardwareID{
+			BundleID:            t.BundleID,
+			InfID:               t.InfID,
+			OperatingSystemCode: t.OSCode,
+			PnpString:           t.PnpID,
 		})
 	}
-
-	return map[string]any{
-		"publishingSpecifications": publishing,
-		"targeting": map[string]any{
-			"hardwareIds": hwids,
-			"chids":       chidArr,
-		},
-		"name":        name,
-		"destination": opt.Destination,
-	}, nil
+	return out
 }
@@ -0,0 +1,110 @@
+package shippinglabel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"WU/internal/drivermeta"
+	"WU/internal/support"
+)
+
+// Split modes for --split-by.
+const (
+	SplitByCount  = "count"
+	SplitByBundle = "bundle"
+	SplitByOS     = "os"
+)
+
+// DefaultNameTemplate names the labels of a split selection.
+const DefaultNameTemplate = "{name} ({index}/{total})"
+
+// Part is one label's share of a split selection.
+type Part struct {
+	Bundle  string // bundle ID, or "mixed"
+	OS      string // operating system code, or "mixed"
+	Targets []drivermeta.HardwareTarget
+}
+
+// SplitTargets partitions targets for several labels. by is "", count, bundle
+// or os; max > 0 additionally caps each part. Order within and across parts
+// follows the selection order. With by == "" and max == 0 there is one part.
+func SplitTargets(targets []drivermeta.HardwareTarget, by string, max int) ([]Part, error) {
+	var groups [][]drivermeta.HardwareTarget
+	switch by {
+	case "", SplitByCount:
+		if by == SplitByCount && max <= 0 {
+			return nil, support.NewAPIError("--split-by count 需要同时指定 --max-targets")
+		}
+		groups = [][]drivermeta.HardwareTarget{targets}
+	case SplitByBundle:
+		groups = groupBy(targets, func(t drivermeta.HardwareTarget) string { return t.BundleID })
+	case SplitByOS:
+		groups = groupBy(targets, func(t drivermeta.HardwareTarget) string { return strings.ToUpper(t.OSCode) })
+	default:
+		return nil, support.NewAPIError(fmt.Sprintf("--split-by 不支持 %q（可用: %s, %s, %s）", by, SplitByCount, SplitByBundle, SplitByOS))
+	}
+
+	var parts []Part
+	for _, g := range groups {
+		for len(g) > 0 {
+			n := len(g)
+			if max > 0 && n > max {
+				n = max
+			}
+			parts = append(parts, newPart(g[:n]))
+			g = g[n:]
+		}
+	}
+	return parts, nil
+}
+
+func groupBy(targets []drivermeta.HardwareTarget, key func(drivermeta.HardwareTarget) string) [][]drivermeta.HardwareTarget {
+	index := map[string]int{}
+	var groups [][]drivermeta.HardwareTarget
+	for _, t := range targets {
+		k := key(t)
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	return groups
+}
+
+func newPart(targets []drivermeta.HardwareTarget) Part {
+	p := Part{Targets: targets}
+	for i, t := range targets {
+		if i == 0 {
+			p.Bundle, p.OS = t.BundleID, t.OSCode
+			continue
+		}
+		if t.BundleID != p.Bundle {
+			p.Bundle = "mixed"
+		}
+		if !strings.EqualFold(t.OSCode, p.OS) {
+			p.OS = "mixed"
+		}
+	}
+	return p
+}
+
+// ExpandNameTemplate fills a label name template. Placeholders: {name},
+// {index}, {total}, {count}, {bundle} and {os} (e.g. "W11 22H2 x64").
+func ExpandNameTemplate(tmpl, name string, p Part, index, total int) string {
+	osName := p.OS
+	if osName != "mixed" {
+		osName = drivermeta.ParseOSCode(p.OS).ShortName()
+	}
+	return strings.NewReplacer(
+		"{name}", name,
+		"{index}", strconv.Itoa(index),
+		"{total}", strconv.Itoa(total),
+		"{count}", strconv.Itoa(len(p.Targets)),
+		"{bundle}", p.Bundle,
+		"{os}", osName,
+	).Replace(tmpl)
+}
@@ -0,0 +1,68 @@
+package shippinglabel
+
+import (
+	"testing"
+
+	"WU/internal/drivermeta"
+)
+
+func splitTargets() []drivermeta.HardwareTarget {
+	return []drivermeta.HardwareTarget{
+		{BundleID: "b1", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_NI_FULL", PnpID: "PCI\\VEN_1"},
+		{BundleID: "b2", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_RS5_FULL", PnpID: "PCI\\VEN_1"},
+		{BundleID: "b1", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_NI_FULL", PnpID: "PCI\\VEN_2"},
+		{BundleID: "b1", InfID: "a.inf", OSCode: "WINDOWS_v100_X64_RS5_FULL", PnpID: "PCI\\VEN_3"},
+	}
+}
+
+func TestSplitTargets(t *testing.T) {
+	tests := []struct {
+		by    string
+		max   int
+		sizes []int
+	}{
+		{"", 0, []int{4}},
+		{"count", 3, []int{3, 1}},
+		{"bundle", 0, []int{3, 1}},
+		{"bundle", 2, []int{2, 1, 1}},
+		{"os", 0, []int{2, 2}},
+	}
+	for _, tt := range tests {
+		parts, err := SplitTargets(splitTargets(), tt.by, tt.max)
+		if err != nil {
+			t.Fatalf("SplitTargets(%q, %d) error: %v", tt.by, tt.max, err)
+		}
+		var sizes []int
+		for _, p := range parts {
+			sizes = append(sizes, len(p.Targets))
+		}
+		if len(sizes) != len(tt.sizes) {
+			t.Errorf("SplitTargets(%q, %d) sizes = %v, want %v", tt.by, tt.max, sizes, tt.sizes)
+			continue
+		}
+		for i := range sizes {
+			if sizes[i] != tt.sizes[i] {
+				t.Errorf("SplitTargets(%q, %d) sizes = %v, want %v", tt.by, tt.max, sizes, tt.sizes)
+				break
+			}
+		}
+	}
+
+	if _, err := SplitTargets(splitTargets(), "count", 0); err == nil {
+		t.Errorf("expected error for count without max")
+	}
+	if _, err := SplitTargets(splitTargets(), "inf", 0); err == nil {
+		t.Errorf("expected error for unknown mode")
+	}
+}
+
+func TestExpandNameTemplate(t *testing.T) {
+	parts, _ := SplitTargets(splitTargets(), "os", 0)
+	got := ExpandNameTemplate("{name} - {os} ({index}/{total}, {count})", "OEM: P", parts[0], 1, 2)
+	if want := "OEM: P - W11 22H2 x64 (1/2, 2)"; got != want {
+		t.Errorf("ExpandNameTemplate() = %q, want %q", got, want)
+	}
+	if parts[1].Bundle != "mixed" {
+		t.Errorf("Bundle = %q, want mixed", parts[1].Bundle)
+	}
+}
@@ -0,0 +1,136 @@
+package shippinglabel
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Request is the body of POST /products/{id}/submissions/{id}/shippingLabels.
+//
+// windowsUpdate labels carry PublishingSpecifications; anotherPartner labels
+// carry RecipientSpecifications instead.
+type Request struct {
+	PublishingSpecifications *PublishingSpecifications `json:"publishingSpecifications,omitempty"`
+	RecipientSpecifications  *RecipientSpecifications  `json:"recipientSpecifications,omitempty"`
+	Targeting                Targeting                 `json:"targeting"`
+	Name                     string                    `json:"name"`
+	Destination              string                    `json:"destination"`
+}
+
+type PublishingSpecifications struct {
+	GoLiveDate                       string                       `json:"goLiveDate"`
+	VisibleToAccounts                []int                        `json:"visibleToAccounts"`
+	IsAutoInstallDuringOSUpgrade     bool                         `json:"isAutoInstallDuringOSUpgrade"`
+	IsAutoInstallOnApplicableSystems bool                         `json:"isAutoInstallOnApplicableSystems"`
+	ManualAcquisition                bool                         `json:"manualAcquisition"`
+	IsDisclosureRestricted           bool                         `json:"isDisclosureRestricted"`
+	PublishToWindows10s              bool                         `json:"publishToWindows10s"`
+	AdditionalInfoForMsApproval      *AdditionalInfoForMsApproval `json:"additionalInfoForMsApproval,omitempty"`
+
+	// Scheduled records that a go-live date was asked for
+	// (--schedule-go-live); a blank GoLiveDate alone means immediate.
+	Scheduled bool `json:"-"`
+}
+
+// AutoInstall reports whether either auto-install option is on, which makes
+// the label go through Microsoft approval.
+func (p *PublishingSpecifications) AutoInstall() bool {
+	return p.IsAutoInstallDuringOSUpgrade || p.IsAutoInstallOnApplicableSystems
+}
+
+type AdditionalInfoForMsApproval struct {
+	MicrosoftContact        string   `json:"microsoftContact"`
+	ValidationsPerformed    string   `json:"validationsPerformed"`
+	AffectedOems            []string `json:"affectedOems"`
+	IsRebootRequired        bool     `json:"isRebootRequired"`
+	IsCoEngineered          bool     `json:"isCoEngineered"`
+	IsForUnreleasedHardware bool     `json:"isForUnreleasedHardware"`
+	HasUiSoftware           bool     `json:"hasUiSoftware"`
+	BusinessJustification   string   `json:"businessJustification"`
+}
+
+// RecipientSpecifications names the partner a driver is shared with.
+// EnforceChidTargeting keeps the partner from publishing the driver beyond
+// the CHIDs in this label.
+type RecipientSpecifications struct {
+	ReceiverPublisherID  string `json:"receiverPublisherId"`
+	EnforceChidTargeting bool   `json:"enforceChidTargeting"`
+}
+
+type Targeting struct {
+	HardwareIDs          []HardwareID          `json:"hardwareIds"`
+	Chids                []CHID                `json:"chids"`
+	InServicePublishInfo *InServicePublishInfo `json:"inServicePublishInfo,omitempty"`
+	// RestrictedToAudiences limits the label to audience IDs (pilot rings).
+	RestrictedToAudiences []string `json:"restrictedToAudiences,omitempty"`
+}
+
+// InServicePublishInfo limits a label to a range of Windows releases. Both
+// ends are release tokens (RS5, CO, NI ...); either may be empty.
+type InServicePublishInfo struct {
+	Flooring string `json:"flooring,omitempty"`
+	Ceiling  string `json:"ceiling,omitempty"`
+}
+
+type HardwareID struct {
+	BundleID            string `json:"bundleId"`
+	InfID               string `json:"infId"`
+	OperatingSystemCode string `json:"operatingSystemCode"`
+	PnpString           string `json:"pnpString"`
+}
+
+type CHID struct {
+	Chid              string `json:"chid"`
+	DistributionState string `json:"distributionState"`
+}
+
+const (
+	DestinationWindowsUpdate  = "windowsUpdate"
+	DestinationAnotherPartner = "anotherPartner"
+
+	DistributionPendingAdd    = "pendingAdd"
+	DistributionPendingRemove = "pendingRemove"
+)
+
+// SetAudiences replaces restrictedToAudiences, dropping blanks and
+// duplicates; an empty list removes the restriction.
+func (r *Request) SetAudiences(ids []string) {
+	r.Targeting.RestrictedToAudiences = nil
+	seen := map[string]bool{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[strings.ToLower(id)] {
+			continue
+		}
+		seen[strings.ToLower(id)] = true
+		r.Targeting.RestrictedToAudiences = append(r.Targeting.RestrictedToAudiences, id)
+	}
+}
+
+// SetCHIDs replaces the targeted CHIDs with pendingAdd entries.
+func (r *Request) SetCHIDs(chids []string) {
+	r.Targeting.Chids = make([]CHID, 0, len(chids))
+	for _, c := range chids {
+		r.Targeting.Chids = append(r.Targeting.Chids, CHID{Chid: c, DistributionState: DistributionPendingAdd})
+	}
+}
+
+// Audience is one entry of GET /audiences.
+type Audience struct {
+	ID           string `json:"id"`
+	AudienceName string `json:"audienceName"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+}
+
+// Label is a shipping label as returned by GET .../shippingLabels.
+type Label struct {
+	ID             json.Number     `json:"id"`
+	WorkflowStatus *WorkflowStatus `json:"workflowStatus,omitempty"`
+	Request
+}
+
+type WorkflowStatus struct {
+	CurrentStep string `json:"currentStep"`
+	State       string `json:"state"`
+}
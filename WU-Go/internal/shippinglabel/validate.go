@@ -0,0 +1,240 @@
+package shippinglabel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"WU/internal/drivermeta"
+	"WU/internal/format"
+	"WU/internal/support"
+	"WU/internal/validate"
+)
+
+// ValidationError lists every problem found in a request.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "请求体校验失败:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// NormalizeGoLiveDate accepts "", YYYY-MM-DD or RFC 3339 and returns the
+// RFC 3339 UTC form Dev Center expects ("" stays "").
+func NormalizeGoLiveDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return "", support.NewAPIError("goLiveDate 格式无效（需 YYYY-MM-DD 或 RFC 3339，如 2025-01-31T08:00:00Z）: " + s)
+}
+
+// ReadRequest decodes a saved request body. Unknown fields are rejected so
+// typos in hand-edited files surface before the POST.
+func ReadRequest(b []byte) (*Request, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var req Request
+	if err := dec.Decode(&req); err != nil {
+		return nil, support.NewAPIError("请求体不符合 shipping label 结构: " + err.Error())
+	}
+	return &req, nil
+}
+
+// DecodeLabel converts one item of a shippingLabels listing. Unknown fields
+// are ignored: the service returns more than a request carries.
+func DecodeLabel(obj map[string]any) (*Label, error) {
+	var l Label
+	if err := json.Unmarshal(format.MustJSON(obj), &l); err != nil {
+		return nil, support.NewAPIError("shipping label 结构无法解析: " + err.Error())
+	}
+	return &l, nil
+}
+
+// DecodeAudiences converts a GET /audiences listing.
+func DecodeAudiences(items []map[string]any) ([]Audience, error) {
+	var out []Audience
+	if err := json.Unmarshal(format.MustJSON(items), &out); err != nil {
+		return nil, support.NewAPIError("audience 结构无法解析: " + err.Error())
+	}
+	return out, nil
+}
+
+// Validate checks a request against the rules Dev Center enforces and
+// returns a *ValidationError listing all problems, or nil.
+func Validate(req *Request, now time.Time) error {
+	var problems []string
+	add := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if support.IsBlank(req.Name) {
+		add("name: 不能为空")
+	}
+
+	// targeting
+	if len(req.Targeting.HardwareIDs) == 0 {
+		add("targeting.hardwareIds: 至少需要 1 个 hardware ID")
+	}
+	seen := map[string]int{}
+	for i, h := range req.Targeting.HardwareIDs {
+		for _, f := range []struct{ name, value string }{
+			{"bundleId", h.BundleID}, {"infId", h.InfID},
+			{"operatingSystemCode", h.OperatingSystemCode}, {"pnpString", h.PnpString},
+		} {
+			if support.IsBlank(f.value) {
+				add("targeting.hardwareIds[%d].%s: 不能为空", i, f.name)
+			}
+		}
+		key := strings.ToLower(h.BundleID + "|" + h.InfID + "|" + h.OperatingSystemCode + "|" + h.PnpString)
+		if j, dup := seen[key]; dup {
+			add("targeting.hardwareIds[%d]: 与 [%d] 重复", i, j)
+		} else {
+			seen[key] = i
+		}
+	}
+	for i, c := range req.Targeting.Chids {
+		if _, err := validate.NormalizeCHIDsRequired([]string{c.Chid}); err != nil {
+			add("targeting.chids[%d].chid: %s", i, err.Error())
+		}
+		if support.IsBlank(c.DistributionState) {
+			add("targeting.chids[%d].distributionState: 不能为空", i)
+		}
+	}
+
+	if isp := req.Targeting.InServicePublishInfo; isp != nil {
+		problems = append(problems, validateInService(isp)...)
+		if req.Destination == DestinationAnotherPartner {
+			add("targeting.inServicePublishInfo: 仅用于 windowsUpdate")
+		}
+	}
+
+	seenAud := map[string]int{}
+	for i, a := range req.Targeting.RestrictedToAudiences {
+		if support.IsBlank(a) {
+			add("targeting.restrictedToAudiences[%d]: 不能为空", i)
+		} else if j, dup := seenAud[strings.ToLower(a)]; dup {
+			add("targeting.restrictedToAudiences[%d]: 与 [%d] 重复", i, j)
+		} else {
+			seenAud[strings.ToLower(a)] = i
+		}
+	}
+	if len(req.Targeting.RestrictedToAudiences) > 0 && req.Destination == DestinationAnotherPartner {
+		add("targeting.restrictedToAudiences: 仅用于 windowsUpdate")
+	}
+
+	// destination-specific
+	switch req.Destination {
+	case DestinationWindowsUpdate:
+		if len(req.Targeting.Chids) == 0 {
+			add("targeting.chids: windowsUpdate 至少需要 1 个 CHID")
+		}
+		if req.PublishingSpecifications == nil {
+			add("publishingSpecifications: windowsUpdate 必须提供")
+		} else {
+			problems = append(problems, validatePublishing(req.PublishingSpecifications, now)...)
+		}
+		if req.RecipientSpecifications != nil {
+			add("recipientSpecifications: 仅用于 anotherPartner")
+		}
+	case DestinationAnotherPartner:
+		r := req.RecipientSpecifications
+		if r == nil || support.IsBlank(r.ReceiverPublisherID) {
+			add("recipientSpecifications.receiverPublisherId: anotherPartner 必须提供合作伙伴 publisher ID")
+		}
+		if r != nil && r.EnforceChidTargeting && len(req.Targeting.Chids) == 0 {
+			add("targeting.chids: enforceChidTargeting 开启时至少需要 1 个 CHID")
+		}
+		if req.PublishingSpecifications != nil {
+			add("publishingSpecifications: anotherPartner 不使用发布设置，请删除")
+		}
+	case "":
+		add("destination: 不能为空")
+	default:
+		add("destination: 不支持 %q（可用: %s, %s）", req.Destination, DestinationWindowsUpdate, DestinationAnotherPartner)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validatePublishing(p *PublishingSpecifications, now time.Time) []string {
+	var problems []string
+	add := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if p.Scheduled && support.IsBlank(p.GoLiveDate) {
+		add("publishingSpecifications.goLiveDate: 已要求定时上线 (--schedule-go-live)，但没有日期；请用 --go-live-date 指定，否则会立即上线")
+	}
+	if !support.IsBlank(p.GoLiveDate) {
+		norm, err := NormalizeGoLiveDate(p.GoLiveDate)
+		if err != nil {
+			add("publishingSpecifications.%s", err.Error())
+		} else if t, _ := time.Parse(time.RFC3339, norm); !t.After(now) {
+			add("publishingSpecifications.goLiveDate: %s 不是将来的时间", norm)
+		}
+	}
+
+	if p.ManualAcquisition && p.AutoInstall() {
+		add("publishingSpecifications.manualAcquisition: 开启自动安装时不能为 true")
+	}
+	if !p.ManualAcquisition && !p.AutoInstall() {
+		add("publishingSpecifications.manualAcquisition: 两个自动安装选项都关闭时必须为 true")
+	}
+
+	if p.AutoInstall() {
+		a := p.AdditionalInfoForMsApproval
+		if a == nil {
+			add("publishingSpecifications.additionalInfoForMsApproval: 开启自动安装时必须提供")
+		} else {
+			if _, err := mail.ParseAddress(a.MicrosoftContact); err != nil {
+				add("additionalInfoForMsApproval.microsoftContact: 需要有效邮箱地址: %q", a.MicrosoftContact)
+			}
+			if support.IsBlank(a.ValidationsPerformed) {
+				add("additionalInfoForMsApproval.validationsPerformed: 不能为空")
+			}
+			if support.IsBlank(a.BusinessJustification) {
+				add("additionalInfoForMsApproval.businessJustification: 不能为空")
+			}
+			if len(a.AffectedOems) == 0 {
+				add("additionalInfoForMsApproval.affectedOems: 至少需要 1 项")
+			}
+		}
+	}
+	return problems
+}
+
+func validateInService(isp *InServicePublishInfo) []string {
+	var problems []string
+	ranks := map[string]int{}
+	for _, f := range []struct{ name, value string }{{"flooring", isp.Flooring}, {"ceiling", isp.Ceiling}} {
+		if support.IsBlank(f.value) {
+			continue
+		}
+		token, ok := drivermeta.ResolveReleaseToken(f.value)
+		if !ok || token != f.value {
+			problems = append(problems, fmt.Sprintf("targeting.inServicePublishInfo.%s: 未知的 release %q（可用: %s）",
+				f.name, f.value, strings.Join(drivermeta.ReleaseTokens(), ", ")))
+			continue
+		}
+		ranks[f.name], _ = drivermeta.ParseReleaseRank(token)
+	}
+	if support.IsBlank(isp.Flooring) && support.IsBlank(isp.Ceiling) {
+		problems = append(problems, "targeting.inServicePublishInfo: flooring 与 ceiling 至少提供一个")
+	}
+	if f, ok := ranks["flooring"]; ok {
+		if c, ok := ranks["ceiling"]; ok && f > c {
+			problems = append(problems, fmt.Sprintf("targeting.inServicePublishInfo: flooring %s 晚于 ceiling %s", isp.Flooring, isp.Ceiling))
+		}
+	}
+	return problems
+}
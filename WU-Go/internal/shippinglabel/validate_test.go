@@ -0,0 +1,150 @@
+package shippinglabel
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func validRequest() *Request {
+	req := &Request{
+		Name:        "OEM: Project",
+		Destination: DestinationWindowsUpdate,
+		PublishingSpecifications: &PublishingSpecifications{
+			IsAutoInstallOnApplicableSystems: true,
+			AdditionalInfoForMsApproval: &AdditionalInfoForMsApproval{
+				MicrosoftContact:      "someone@microsoft.com",
+				ValidationsPerformed:  "full range",
+				AffectedOems:          []string{"N/A"},
+				BusinessJustification: "MDA",
+			},
+		},
+	}
+	req.Targeting.HardwareIDs = []HardwareID{{BundleID: "b", InfID: "x.inf", OperatingSystemCode: "WINDOWS_v100_X64_NI_FULL", PnpString: "PCI\\VEN_1"}}
+	req.SetCHIDs([]string{"5b4e5bf1-a6ad-5e24-8c43-fd4b4f9b3fd3"})
+	return req
+}
+
+func TestValidateAcceptsValidRequest(t *testing.T) {
+	if err := Validate(validRequest(), time.Now()); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestValidateCollectsAllProblems(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	req := validRequest()
+	req.Name = ""
+	req.Targeting.Chids = nil
+	req.PublishingSpecifications.GoLiveDate = "2025-05-01"
+	req.PublishingSpecifications.AdditionalInfoForMsApproval = nil
+
+	err := Validate(req, now)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want *ValidationError", err)
+	}
+	if len(verr.Problems) != 4 {
+		t.Errorf("got %d problems, want 4: %v", len(verr.Problems), verr.Problems)
+	}
+}
+
+func TestValidateScheduledNeedsDate(t *testing.T) {
+	req := validRequest()
+	req.PublishingSpecifications.Scheduled = true
+	err := Validate(req, time.Now())
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 1 {
+		t.Fatalf("Validate() = %v, want the missing go-live date", err)
+	}
+
+	req.PublishingSpecifications.GoLiveDate = time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	if err := Validate(req, time.Now()); err != nil {
+		t.Fatalf("Validate() with a date = %v", err)
+	}
+}
+
+func TestNormalizeGoLiveDate(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"2030-01-31":                "2030-01-31T00:00:00Z",
+		"2030-01-31T08:00:00+08:00": "2030-01-31T00:00:00Z",
+	}
+	for in, want := range tests {
+		got, err := NormalizeGoLiveDate(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeGoLiveDate(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := NormalizeGoLiveDate("31/01/2030"); err == nil {
+		t.Errorf("expected error for non-ISO date")
+	}
+}
+
+func TestValidateAnotherPartner(t *testing.T) {
+	req := validRequest()
+	req.Destination = DestinationAnotherPartner
+	if err := Validate(req, time.Now()); err == nil {
+		t.Fatalf("expected problems for partner label with publishing specs and no recipient")
+	}
+
+	req.PublishingSpecifications = nil
+	req.RecipientSpecifications = &RecipientSpecifications{ReceiverPublisherID: "12345", EnforceChidTargeting: true}
+	req.Targeting.Chids = nil
+	err := Validate(req, time.Now())
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 1 {
+		t.Fatalf("Validate() = %v, want only the enforceChidTargeting problem", err)
+	}
+
+	req.RecipientSpecifications.EnforceChidTargeting = false
+	if err := Validate(req, time.Now()); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestValidateInServiceRange(t *testing.T) {
+	req := validRequest()
+	req.Targeting.InServicePublishInfo = InServiceRange("1809", "22H2")
+	if got := *req.Targeting.InServicePublishInfo; got.Flooring != "RS5" || got.Ceiling != "NI" {
+		t.Fatalf("InServiceRange(1809, 22H2) = %+v", got)
+	}
+	if err := Validate(req, time.Now()); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	req.Targeting.InServicePublishInfo = InServiceRange("NI", "RS5")
+	if err := Validate(req, time.Now()); err == nil {
+		t.Errorf("expected a problem for floor after ceiling")
+	}
+	req.Targeting.InServicePublishInfo = InServiceRange("XP", "")
+	if err := Validate(req, time.Now()); err == nil {
+		t.Errorf("expected a problem for unknown release")
+	}
+}
+
+func TestDecodeLabelKeepsLargeID(t *testing.T) {
+	l, err := DecodeLabel(map[string]any{"id": json.Number("1152921504606980300"), "name": "x"})
+	if err != nil || l.ID.String() != "1152921504606980300" {
+		t.Fatalf("DecodeLabel() = %+v, %v", l, err)
+	}
+}
+
+func TestSetAudiences(t *testing.T) {
+	req := validRequest()
+	req.SetAudiences([]string{" pilot ", "", "PILOT", "ring1"})
+	if got := req.Targeting.RestrictedToAudiences; len(got) != 2 || got[0] != "pilot" || got[1] != "ring1" {
+		t.Fatalf("RestrictedToAudiences = %q", got)
+	}
+	if err := Validate(req, time.Now()); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	req.Destination = DestinationAnotherPartner
+	req.PublishingSpecifications = nil
+	req.RecipientSpecifications = &RecipientSpecifications{ReceiverPublisherID: "12345"}
+	if err := Validate(req, time.Now()); err == nil {
+		t.Errorf("expected a problem for audiences on a partner label")
+	}
+}
@@ -1,6 +1,7 @@
 package support
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 )
@@ -44,6 +45,9 @@ func TryGetInt64(obj map[string]any, key string) (int64, bool) {
 	case string:
 		n, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
 		return n, err == nil
+	case json.Number:
+		n, err := t.Int64()
+		return n, err == nil
 	default:
 		return 0, false
 	}
@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"WU/internal/format"
+)
+
+// TerminationSignals should end a full-screen UI, restoring the terminal.
+var TerminationSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// watchResize reports terminal size changes. Windows consoles have no
+// SIGWINCH, so the size is polled. stop releases it.
+func watchResize() (resized <-chan struct{}, stop func()) {
+	ch := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(250 * time.Millisecond)
+		defer t.Stop()
+		w, h := format.TermSizeBestEffort()
+		for {
+			select {
+			case <-t.C:
+				if nw, nh := format.TermSizeBestEffort(); nw != w || nh != h {
+					w, h = nw, nh
+					select {
+					case ch <- struct{}{}:
+					default:
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch, func() { close(done) }
+}
@@ -0,0 +1,95 @@
+// Package terminal is the console the prompts and the TUI talk to. The
+// process console is Std; tests swap in a Virtual terminal driven by
+// scripted keys.
+package terminal
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"WU/internal/format"
+)
+
+// Terminal is an input reader, output writer and size provider.
+type Terminal interface {
+	io.Reader
+	io.Writer
+
+	// Interactive reports whether input comes from a user at a terminal,
+	// who can answer prompts.
+	Interactive() bool
+	// Size is the usable width and height (one less than the window, as
+	// format.TermSizeBestEffort reports it).
+	Size() (width, height int)
+	// MakeRaw switches input to raw mode until restore is called.
+	MakeRaw() (restore func(), err error)
+	// ReadSecret reads a line without echoing it.
+	ReadSecret() (string, error)
+	// WatchResize reports size changes until stop is called.
+	WatchResize() (resized <-chan struct{}, stop func())
+}
+
+var current Terminal = Std{}
+
+// Current is the terminal prompts and the TUI use.
+func Current() Terminal { return current }
+
+// Use makes t the current terminal; restore puts the previous one back.
+func Use(t Terminal) (restore func()) {
+	prev := current
+	current = t
+	return func() { current = prev }
+}
+
+// ReadLine reads one line (without the line ending) a byte at a time, so
+// nothing after it is consumed from r. At end of input it returns what
+// was read and io.EOF.
+func ReadLine(r io.Reader) (string, error) {
+	var sb strings.Builder
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n == 1 {
+			if b[0] == '\n' {
+				return strings.TrimSuffix(sb.String(), "\r"), nil
+			}
+			sb.WriteByte(b[0])
+		}
+		if err != nil {
+			return strings.TrimSuffix(sb.String(), "\r"), err
+		}
+	}
+}
+
+// Std is the process console: os.Stdin and os.Stdout.
+type Std struct{}
+
+func (Std) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (Std) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (Std) Size() (int, int)            { return format.TermSizeBestEffort() }
+func (Std) Interactive() bool           { return term.IsTerminal(int(os.Stdin.Fd())) }
+
+func (Std) MakeRaw() (func(), error) {
+	fd := int(os.Stdin.Fd())
+	old, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() { term.Restore(fd, old) }, nil
+}
+
+func (Std) ReadSecret() (string, error) {
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	return string(b), err
+}
+
+func (Std) WatchResize() (<-chan struct{}, func()) { return watchResize() }
+
+// Stderr is the process console with output on os.Stderr, for progress
+// that must stay out of machine-readable output on stdout.
+type Stderr struct{ Std }
+
+func (Stderr) Write(p []byte) (int, error) { return os.Stderr.Write(p) }
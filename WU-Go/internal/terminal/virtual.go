@@ -0,0 +1,364 @@
+package terminal
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Virtual is an in-memory terminal for tests. Input is a scripted byte
+// sequence; output is interpreted by a small VT100 subset (cursor
+// position, erase, alternate screen, SGR is dropped) into a screen grid
+// where wide (CJK) runes take two cells.
+// Each time the program reads input the screen is recorded as a frame, so
+// a test sees what the user would have seen before each key.
+type Virtual struct {
+	mu sync.Mutex
+
+	width, height int
+	main, alt     [][]rune
+	useAlt        bool
+	row, col      int
+	savedRow      int
+	savedCol      int
+	raw           bool
+	batch         bool
+
+	input   []byte
+	pending []byte // incomplete escape sequence or rune from the last Write
+	frames  []string
+	resized chan struct{}
+}
+
+// NewVirtual returns a width×height terminal whose input is the given
+// keys. Reads past the end of input return io.EOF.
+func NewVirtual(width, height int, keys string) *Virtual {
+	v := &Virtual{
+		width:   width,
+		height:  height,
+		input:   []byte(keys),
+		resized: make(chan struct{}, 1),
+	}
+	v.main = blankGrid(width, height)
+	v.alt = blankGrid(width, height)
+	return v
+}
+
+func blankGrid(width, height int) [][]rune {
+	g := make([][]rune, height)
+	for i := range g {
+		g[i] = blankRow(width)
+	}
+	return g
+}
+
+func blankRow(width int) []rune {
+	r := make([]rune, width)
+	for i := range r {
+		r[i] = ' '
+	}
+	return r
+}
+
+// Type appends keys to the input script.
+func (v *Virtual) Type(keys string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.input = append(v.input, keys...)
+}
+
+// Read records a frame and hands out scripted input, at most one line at
+// a time. Outside raw mode the input is echoed, as a terminal would.
+func (v *Virtual) Read(p []byte) (int, error) { return v.read(p, true) }
+
+func (v *Virtual) read(p []byte, echo bool) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s := v.screen(); len(v.frames) == 0 || v.frames[len(v.frames)-1] != s {
+		v.frames = append(v.frames, s)
+	}
+	if len(v.input) == 0 {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && n < len(v.input) {
+		p[n] = v.input[n]
+		n++
+		if p[n-1] == '\n' {
+			break
+		}
+	}
+	v.input = v.input[n:]
+	if echo && !v.raw {
+		v.write(p[:n])
+	}
+	return n, nil
+}
+
+// Write interprets output onto the screen.
+func (v *Virtual) Write(p []byte) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.write(p)
+	return len(p), nil
+}
+
+func (v *Virtual) write(p []byte) {
+	b := append(v.pending, p...)
+	v.pending = nil
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			n := v.escape(b)
+			if n == 0 {
+				v.pending = append([]byte(nil), b...)
+				break
+			}
+			b = b[n:]
+			continue
+		}
+		if !utf8.FullRune(b) {
+			v.pending = append([]byte(nil), b...)
+			break
+		}
+		r, size := utf8.DecodeRune(b)
+		v.put(r)
+		b = b[size:]
+	}
+}
+
+// escape applies the sequence at the start of b and returns its length,
+// or 0 if b ends before the sequence does.
+func (v *Virtual) escape(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	if b[1] != '[' {
+		return 2
+	}
+	i := 2
+	for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+		i++
+	}
+	if i == len(b) {
+		return 0
+	}
+	v.csi(string(b[2:i]), b[i])
+	return i + 1
+}
+
+func (v *Virtual) csi(params string, final byte) {
+	if strings.HasPrefix(params, "?") {
+		if params == "?1049" {
+			switch final {
+			case 'h':
+				v.savedRow, v.savedCol = v.row, v.col
+				v.alt = blankGrid(v.width, v.height)
+				v.useAlt = true
+			case 'l':
+				v.useAlt = false
+				v.row, v.col = v.savedRow, v.savedCol
+			}
+		}
+		return
+	}
+	args := strings.Split(params, ";")
+	arg := func(i, def int) int {
+		if i < len(args) {
+			if n, err := strconv.Atoi(args[i]); err == nil {
+				return n
+			}
+		}
+		return def
+	}
+	switch final {
+	case 'H', 'f':
+		v.row = clamp(arg(0, 1)-1, 0, v.height-1)
+		v.col = clamp(arg(1, 1)-1, 0, v.width-1)
+	case 'A':
+		v.row = clamp(v.row-arg(0, 1), 0, v.height-1)
+	case 'B':
+		v.row = clamp(v.row+arg(0, 1), 0, v.height-1)
+	case 'C':
+		v.col = clamp(v.col+arg(0, 1), 0, v.width-1)
+	case 'D':
+		v.col = clamp(v.col-arg(0, 1), 0, v.width-1)
+	case 'J':
+		g := v.grid()
+		switch arg(0, 0) {
+		case 2, 3:
+			for i := range g {
+				g[i] = blankRow(v.width)
+			}
+		default:
+			v.eraseLine(v.col)
+			for i := v.row + 1; i < v.height; i++ {
+				g[i] = blankRow(v.width)
+			}
+		}
+	case 'K':
+		v.eraseLine(v.col)
+	}
+}
+
+func (v *Virtual) eraseLine(from int) {
+	line := v.grid()[v.row]
+	for i := from; i < len(line); i++ {
+		line[i] = ' '
+	}
+}
+
+func (v *Virtual) put(r rune) {
+	switch r {
+	case '\r':
+		v.col = 0
+	case '\n':
+		// Outside raw mode the terminal driver turns "\n" into "\r\n".
+		if !v.raw {
+			v.col = 0
+		}
+		v.lineFeed()
+	case '\b':
+		if v.col > 0 {
+			v.col--
+		}
+	case '\t':
+		v.col = min(v.col+8-v.col%8, v.width-1)
+	default:
+		w := runewidth.RuneWidth(r)
+		if r < ' ' || w == 0 {
+			return
+		}
+		if v.col+w > v.width {
+			v.col = 0
+			v.lineFeed()
+		}
+		line := v.grid()[v.row]
+		line[v.col] = r
+		if w == 2 {
+			line[v.col+1] = wideTail
+		}
+		v.col += w
+	}
+}
+
+// wideTail fills the second cell of a wide rune.
+const wideTail = -1
+
+func (v *Virtual) lineFeed() {
+	if v.row < v.height-1 {
+		v.row++
+		return
+	}
+	g := v.grid()
+	copy(g, g[1:])
+	g[v.height-1] = blankRow(v.width)
+}
+
+func (v *Virtual) grid() [][]rune {
+	if v.useAlt {
+		return v.alt
+	}
+	return v.main
+}
+
+func (v *Virtual) screen() string {
+	lines := make([]string, 0, v.height)
+	for _, l := range v.grid() {
+		var sb strings.Builder
+		for _, r := range l {
+			if r != wideTail {
+				sb.WriteRune(r)
+			}
+		}
+		lines = append(lines, strings.TrimRight(sb.String(), " "))
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// Screen is the current screen, trailing blanks trimmed.
+func (v *Virtual) Screen() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.screen()
+}
+
+// Frames are the screens seen at each read, consecutive duplicates
+// dropped.
+func (v *Virtual) Frames() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]string(nil), v.frames...)
+}
+
+// Interactive is true unless SetInteractive(false) was called.
+func (v *Virtual) Interactive() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return !v.batch
+}
+
+// SetInteractive makes the terminal look like piped input (false) or a
+// user at a terminal (true), the default.
+func (v *Virtual) SetInteractive(interactive bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.batch = !interactive
+}
+
+// Size reports the usable size, one less than the window like Std.
+func (v *Virtual) Size() (int, int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.width - 1, v.height - 1
+}
+
+// Resize changes the window size and reports it to WatchResize.
+func (v *Virtual) Resize(width, height int) {
+	v.mu.Lock()
+	v.main = resizeGrid(v.main, width, height)
+	v.alt = resizeGrid(v.alt, width, height)
+	v.width, v.height = width, height
+	v.row = clamp(v.row, 0, height-1)
+	v.col = clamp(v.col, 0, width-1)
+	v.mu.Unlock()
+	select {
+	case v.resized <- struct{}{}:
+	default:
+	}
+}
+
+func resizeGrid(g [][]rune, width, height int) [][]rune {
+	out := blankGrid(width, height)
+	for i := 0; i < height && i < len(g); i++ {
+		copy(out[i], g[i])
+	}
+	return out
+}
+
+func (v *Virtual) MakeRaw() (func(), error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.raw = true
+	return func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.raw = false
+	}, nil
+}
+
+// ReadSecret reads a scripted line without echoing it.
+func (v *Virtual) ReadSecret() (string, error) { return ReadLine(silent{v}) }
+
+type silent struct{ v *Virtual }
+
+func (s silent) Read(p []byte) (int, error) { return s.v.read(p, false) }
+
+func (v *Virtual) WatchResize() (<-chan struct{}, func()) { return v.resized, func() {} }
+
+func clamp(n, lo, hi int) int {
+	return max(lo, min(n, hi))
+}
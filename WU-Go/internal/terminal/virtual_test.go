@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"io"
+	"testing"
+)
+
+func TestVirtualAltScreenAndErase(t *testing.T) {
+	v := NewVirtual(10, 4, "")
+	io.WriteString(v, "shell$ ls\n")
+	io.WriteString(v, "\x1b[?1049h\x1b[2J\x1b[H\x1b[31mred\x1b[0m\x1b[2;1Hsecond\x1b[2;4H\x1b[K")
+	if got, want := v.Screen(), "red\nsec\n"; got != want {
+		t.Fatalf("alt screen = %q, want %q", got, want)
+	}
+	io.WriteString(v, "\x1b[?1049l")
+	if got, want := v.Screen(), "shell$ ls\n"; got != want {
+		t.Fatalf("main screen = %q, want %q", got, want)
+	}
+}
+
+func TestVirtualWrapsAndScrolls(t *testing.T) {
+	v := NewVirtual(4, 2, "")
+	io.WriteString(v, "abcdef\ngh")
+	if got, want := v.Screen(), "ef\ngh\n"; got != want {
+		t.Fatalf("screen = %q, want %q", got, want)
+	}
+}
+
+func TestReadLineLeavesTheRestUnread(t *testing.T) {
+	v := NewVirtual(20, 4, "one\r\ntwo\nthree")
+	for _, want := range []string{"one", "two"} {
+		if got, err := ReadLine(v); err != nil || got != want {
+			t.Fatalf("ReadLine = %q, %v; want %q", got, err, want)
+		}
+	}
+	if got, err := ReadLine(v); err != io.EOF || got != "three" {
+		t.Fatalf("last ReadLine = %q, %v; want %q, EOF", got, err, "three")
+	}
+}
+
+func TestVirtualWideRunesTakeTwoCells(t *testing.T) {
+	v := NewVirtual(5, 2, "")
+	io.WriteString(v, "a中文b")
+	if got, want := v.Screen(), "a中文\nb\n"; got != want {
+		t.Fatalf("screen = %q, want %q", got, want)
+	}
+}
@@ -1,10 +1,16 @@
 package tui
 
+import "strconv"
+
 func Reset() string { return "\x1b[0m" }
 func BgDarkGray() string { return "\x1b[100m" }
 func ClearScreen() string { return "\x1b[2J\x1b[H" }
 func HideCursor() string { return "\x1b[?25l" }
 func ShowCursor() string { return "\x1b[?25h" }
+func EnterAltScreen() string { return "\x1b[?1049h" }
+func LeaveAltScreen() string { return "\x1b[?1049l" }
+func MoveTo(row int) string { return "\x1b[" + strconv.Itoa(row+1) + ";1H" }
+func ClearLine() string { return "\x1b[K" }
 
 // Palette mapping matching C# palette intent.
 func Fg(c Color) string {
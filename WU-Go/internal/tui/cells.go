@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Widths here are in terminal cells: CJK and other wide runes take two,
+// ANSI escape sequences none.
+
+// escapeLen is the length of the CSI sequence at the start of s, or 0.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// cellWidth is the number of cells s takes on screen.
+func cellWidth(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w += runewidth.RuneWidth(r)
+		i += size
+	}
+	return w
+}
+
+// truncCells clips s to width cells. A clipped line leaves its last cell
+// free, so it never reaches the right margin. Escape sequences are kept.
+func truncCells(s string, width int) string {
+	if cellWidth(s) <= width {
+		return s
+	}
+	limit := width
+	if width > 1 {
+		limit = width - 1
+	}
+	var b strings.Builder
+	w := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := runewidth.RuneWidth(r)
+		if w+rw > limit {
+			break
+		}
+		b.WriteString(s[i : i+size])
+		w += rw
+		i += size
+	}
+	return b.String()
+}
+
+func padRightCells(s string, width int) string {
+	w := cellWidth(s)
+	if w >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+// wrapCells splits plain text s into pieces of at most width cells. PnP
+// IDs have no spaces to break at, so the wrap is by character.
+func wrapCells(s string, width int) []string {
+	width = max(width, 10)
+	var out []string
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := runewidth.RuneWidth(r)
+		if w+rw > width {
+			out = append(out, b.String())
+			b.Reset()
+			w = 0
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return append(out, b.String())
+}
@@ -0,0 +1,33 @@
+package tui
+
+import "strings"
+
+// match reports whether every space-separated term of query occurs in text
+// (already lowercase), either as a substring or fuzzily as a subsequence.
+// exact is false when at least one term only matched fuzzily.
+func match(text, query string) (ok, exact bool) {
+	exact = true
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if strings.Contains(text, term) {
+			continue
+		}
+		if !subsequence(text, term) {
+			return false, false
+		}
+		exact = false
+	}
+	return true, exact
+}
+
+// subsequence reports whether the runes of term appear in text in order,
+// e.g. "a0c8" in "pci\ven_8086&dev_a0c8".
+func subsequence(text, term string) bool {
+	r := []rune(term)
+	i := 0
+	for _, c := range text {
+		if i < len(r) && c == r[i] {
+			i++
+		}
+	}
+	return i == len(r)
+}
@@ -0,0 +1,25 @@
+package tui
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	text := `b1 net.inf windows_v100_x64 pci\ven_8086&dev_a0c8&subsys_12345678 intel wi-fi 6`
+	cases := []struct {
+		query     string
+		ok, exact bool
+	}{
+		{"", true, true},
+		{"A0C8", true, true},
+		{"net.inf a0c8", true, true},
+		{"ven8086", true, false}, // fuzzy
+		{"a0c8 wifi", true, false},
+		{"a0c9", false, false},
+		{"net.inf 9999", false, false},
+	}
+	for _, c := range cases {
+		ok, exact := match(text, c.query)
+		if ok != c.ok || exact != c.exact {
+			t.Errorf("match(%q) = %v, %v; want %v, %v", c.query, ok, exact, c.ok, c.exact)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package tui
 
 import (
-	"os"
+	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 type keyKind int
@@ -17,6 +19,11 @@ const (
 	keySpace
 	keyEnter
 	keyEsc
+	keyBackspace
+	keyShiftUp
+	keyShiftDown
+	keyLeft
+	keyRight
 )
 
 type keyEvent struct {
@@ -24,10 +31,12 @@ type keyEvent struct {
 	ch   rune
 }
 
-// raw read: supports ↑↓ PgUp PgDn Home End Space Enter Esc + char keys.
-func readKey() (keyEvent, error) {
+// readKey reads one key from r (in raw mode). Supports ↑↓←→ Shift+↑↓ PgUp
+// PgDn Home End Space Enter Esc Backspace + char keys (UTF-8 decoded;
+// control characters are returned as is).
+func readKey(r io.Reader) (keyEvent, error) {
 	var b [1]byte
-	_, err := os.Stdin.Read(b[:])
+	_, err := r.Read(b[:])
 	if err != nil {
 		return keyEvent{}, err
 	}
@@ -36,6 +45,10 @@ func readKey() (keyEvent, error) {
 	if b[0] == '\r' || b[0] == '\n' {
 		return keyEvent{kind: keyEnter}, nil
 	}
+	// Backspace (DEL on most terminals, ^H on some)
+	if b[0] == 0x7f || b[0] == 0x08 {
+		return keyEvent{kind: keyBackspace}, nil
+	}
 	// Space
 	if b[0] == ' ' {
 		return keyEvent{kind: keySpace}, nil
@@ -44,42 +57,71 @@ func readKey() (keyEvent, error) {
 	if b[0] == 0x1b {
 		// If not a sequence, treat as Esc.
 		var x [1]byte
-		_, _ = os.Stdin.Read(x[:])
+		_, _ = r.Read(x[:])
 		if x[0] != '[' {
 			return keyEvent{kind: keyEsc}, nil
 		}
-		_, _ = os.Stdin.Read(x[:])
+		// CSI: parameter bytes up to a final byte in 0x40..0x7e, e.g.
+		// "A", "5~" (PgUp) or "1;2A" (Shift+Up).
+		var params []byte
+		for {
+			if _, err := r.Read(x[:]); err != nil {
+				return keyEvent{}, err
+			}
+			if x[0] >= 0x40 && x[0] <= 0x7e {
+				break
+			}
+			params = append(params, x[0])
+		}
+		return csiKey(string(params), x[0]), nil
+	}
 
-		switch x[0] {
-		case 'A':
-			return keyEvent{kind: keyUp}, nil
-		case 'B':
-			return keyEvent{kind: keyDown}, nil
-		case 'H':
-			return keyEvent{kind: keyHome}, nil
-		case 'F':
-			return keyEvent{kind: keyEnd}, nil
-		case '5': // PgUp: [5~
-			_, _ = os.Stdin.Read(x[:]) // ~
-			return keyEvent{kind: keyPgUp}, nil
-		case '6': // PgDn: [6~
-			_, _ = os.Stdin.Read(x[:])
-			return keyEvent{kind: keyPgDn}, nil
-		case '1': // Home: [1~
-			for {
-				_, _ = os.Stdin.Read(x[:])
-				if x[0] == '~' {
-					break
-				}
+	if b[0] >= utf8.RuneSelf {
+		buf := []byte{b[0]}
+		for !utf8.FullRune(buf) && len(buf) < utf8.UTFMax {
+			if _, err := r.Read(b[:]); err != nil {
+				return keyEvent{}, err
 			}
-			return keyEvent{kind: keyHome}, nil
-		case '4': // End: [4~
-			_, _ = os.Stdin.Read(x[:])
-			return keyEvent{kind: keyEnd}, nil
-		default:
-			return keyEvent{kind: keyEsc}, nil
+			buf = append(buf, b[0])
 		}
+		r, _ := utf8.DecodeRune(buf)
+		return keyEvent{kind: keyChar, ch: r}, nil
 	}
 
 	return keyEvent{kind: keyChar, ch: rune(b[0])}, nil
 }
+
+// csiKey maps a CSI sequence to a key; unknown sequences read as Esc.
+func csiKey(params string, final byte) keyEvent {
+	shift := strings.HasSuffix(params, ";2")
+	switch {
+	case final == 'A' && shift:
+		return keyEvent{kind: keyShiftUp}
+	case final == 'B' && shift:
+		return keyEvent{kind: keyShiftDown}
+	case final == 'A':
+		return keyEvent{kind: keyUp}
+	case final == 'B':
+		return keyEvent{kind: keyDown}
+	case final == 'C':
+		return keyEvent{kind: keyRight}
+	case final == 'D':
+		return keyEvent{kind: keyLeft}
+	case final == 'H':
+		return keyEvent{kind: keyHome}
+	case final == 'F':
+		return keyEvent{kind: keyEnd}
+	case final == '~':
+		switch params {
+		case "5":
+			return keyEvent{kind: keyPgUp}
+		case "6":
+			return keyEvent{kind: keyPgDn}
+		case "1", "7":
+			return keyEvent{kind: keyHome}
+		case "4", "8":
+			return keyEvent{kind: keyEnd}
+		}
+	}
+	return keyEvent{kind: keyEsc}
+}
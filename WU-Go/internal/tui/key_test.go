@@ -0,0 +1,29 @@
+package tui
+
+import "testing"
+
+func TestCSIKey(t *testing.T) {
+	cases := []struct {
+		params string
+		final  byte
+		want   keyKind
+	}{
+		{"", 'A', keyUp},
+		{"", 'B', keyDown},
+		{"1;2", 'A', keyShiftUp},
+		{"1;2", 'B', keyShiftDown},
+		{"5", '~', keyPgUp},
+		{"6", '~', keyPgDn},
+		{"1", '~', keyHome},
+		{"4", '~', keyEnd},
+		{"", 'H', keyHome},
+		{"", 'C', keyRight},
+		{"", 'D', keyLeft},
+		{"2", '~', keyEsc}, // Insert: unsupported
+	}
+	for _, c := range cases {
+		if got := csiKey(c.params, c.final); got.kind != c.want {
+			t.Errorf("csiKey(%q, %q) = %v, want %v", c.params, c.final, got.kind, c.want)
+		}
+	}
+}
@@ -5,4 +5,33 @@ type Color int
 type ListItem struct {
 	Text  string
 	Color Color
+
+	// Filter is the untruncated text / search matches against; Text is
+	// used when empty.
+	Filter string
+
+	// Group keys used by the bundle / INF / OS hotkeys.
+	Bundle string
+	Inf    string
+	OS     string
+
+	// Columns are the values the picker can sort by; s cycles through the
+	// names of the first item's columns.
+	Columns []Field
+
+	// Tree holds the labels of the groups above the item in the tree view
+	// (e.g. bundle, INF, OS); Leaf is the item's own label there, Text
+	// when empty.
+	Tree []string
+	Leaf string
+
+	// Details are shown untruncated in the detail pane for the row under
+	// the cursor.
+	Details []Field
+}
+
+// Field is one labelled value of the detail pane.
+type Field struct {
+	Name  string
+	Value string
 }
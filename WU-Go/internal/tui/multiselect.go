@@ -3,16 +3,17 @@ package tui
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"sort"
 	"strings"
 
-	"golang.org/x/term"
-
-	"WU/internal/format"
 	"WU/internal/support"
+	"WU/internal/terminal"
 )
 
 type Legend struct {
+	Bundle     string // matches ListItem.Bundle
 	Tag        string
 	Color      Color
 	ItemCount  int
@@ -21,180 +22,634 @@ type Legend struct {
 
 // Preferred entry: caller converts to []Legend.
 func RunMultiSelectLegend(title string, legend []Legend, items []ListItem) ([]int, error) {
+	return runMultiSelect(terminal.Current(), title, legend, items)
+}
+
+func runMultiSelect(t terminal.Terminal, title string, legend []Legend, items []ListItem) ([]int, error) {
 	if len(items) == 0 {
 		return nil, support.NewAPIError("没有可选项")
 	}
 
-	fd := int(os.Stdin.Fd())
-	oldState, err := term.MakeRaw(fd)
+	restore, err := t.MakeRaw()
 	if err != nil {
 		return nil, support.NewAPIError("无法进入 raw 模式: " + err.Error())
 	}
-	defer term.Restore(fd, oldState)
+	// The terminal is restored however the list ends, including a panic.
+	defer func() {
+		fmt.Fprint(t, ShowCursor()+LeaveAltScreen())
+		restore()
+		if r := recover(); r != nil {
+			panic(r)
+		}
+	}()
+	fmt.Fprint(t, EnterAltScreen()+HideCursor())
 
-	fmt.Print(HideCursor())
-	defer fmt.Print(ShowCursor())
+	resized, stopResize := t.WatchResize()
+	defer stopResize()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, terminal.TerminationSignals...)
+	defer signal.Stop(sig)
 
-	selected := map[int]bool{}
-	cursor := 0
-	top := 0
+	// One key is read at a time, and only after the screen is drawn, so no
+	// read is left pending when the list is confirmed or canceled.
+	keys := make(chan keyResult, 1)
+	reading := false
 
+	m := newModel(title, legend, items)
+	scr := &screen{out: t}
 	for {
-		draw(title, legend, items, selected, cursor, &top)
+		width, height := t.Size()
+		scr.draw(m.render(width, height), width, height)
+
+		if !reading {
+			reading = true
+			go func() {
+				k, err := readKey(t)
+				keys <- keyResult{k, err}
+			}()
+		}
+
+		select {
+		case <-resized:
+		case <-sig:
+			return nil, support.ErrCanceled
+		case r := <-keys:
+			reading = false
+			if r.err != nil {
+				return nil, r.err
+			}
+			if done, err := m.update(r.key); done {
+				if err != nil {
+					return nil, err
+				}
+				return m.result(), nil
+			}
+		}
+	}
+}
+
+type keyResult struct {
+	key keyEvent
+	err error
+}
+
+// model is the state of the multiselect list. Selection is kept per item
+// index, so rows hidden by the search filter stay selected.
+type model struct {
+	title  string
+	legend []Legend
+	items  []ListItem
+	keys   []string // lowercase search text per item
+
+	selected map[int]bool
+	visible  []int // item indexes shown, in display order
+	cursor   int   // position in visible
+	top      int
+	anchor   int // item index where a range selection starts, or -1
+
+	query     string
+	searching bool
+	shown     map[int]bool // items passing the filter
+
+	order    []int    // item indexes in sort order
+	columns  []string // sortable column names
+	sortCol  int      // index into columns, -1 for the original order
+	sortDesc bool
+
+	showDetails bool
+
+	// tree view: built on first use, rows are the expanded nodes shown
+	tree    bool
+	roots   []*treeNode
+	rows    []*treeNode
+	tcursor int
+	ttop    int
+}
+
+func newModel(title string, legend []Legend, items []ListItem) *model {
+	m := &model{title: title, legend: legend, items: items, selected: map[int]bool{}, anchor: -1, sortCol: -1}
+	m.keys = make([]string, len(items))
+	m.order = make([]int, len(items))
+	for i, it := range items {
+		m.keys[i] = strings.ToLower(support.Or(it.Filter, it.Text))
+		m.order[i] = i
+	}
+	if len(items) > 0 {
+		for _, c := range items[0].Columns {
+			m.columns = append(m.columns, c.Name)
+		}
+	}
+	m.applyFilter()
+	return m
+}
+
+// sortBy orders the rows by column col (-1 keeps the original order),
+// descending if desc. Ties keep the original order. Selection and cursor
+// stay on their items.
+func (m *model) sortBy(col int, desc bool) {
+	m.sortCol, m.sortDesc = col, desc
+	for i := range m.order {
+		m.order[i] = i
+	}
+	if col < 0 {
+		if desc {
+			slices.Reverse(m.order)
+		}
+	} else {
+		vals := make([]string, len(m.items))
+		for i, it := range m.items {
+			for _, c := range it.Columns {
+				if c.Name == m.columns[col] {
+					vals[i] = strings.ToLower(c.Value)
+				}
+			}
+		}
+		sort.SliceStable(m.order, func(a, b int) bool {
+			if desc {
+				return vals[m.order[a]] > vals[m.order[b]]
+			}
+			return vals[m.order[a]] < vals[m.order[b]]
+		})
+	}
+	if m.roots != nil {
+		m.roots = buildTree(m.items, m.order, m.roots)
+	}
+	m.applyFilter()
+}
+
+// sortLabel describes the current order for the title line.
+func (m *model) sortLabel() string {
+	name := "默认"
+	if m.sortCol >= 0 {
+		name = m.columns[m.sortCol]
+	}
+	if m.sortDesc {
+		return name + " ↓"
+	}
+	return name + " ↑"
+}
+
+// applyFilter recomputes the visible rows for the current query: exact
+// matches first, then fuzzy ones, each in sort order. The cursor stays on
+// the same item when it is still visible.
+func (m *model) applyFilter() {
+	cur := -1
+	if m.cursor >= 0 && m.cursor < len(m.visible) {
+		cur = m.visible[m.cursor]
+	}
+	var exact, fuzzy []int
+	for _, i := range m.order {
+		ok, ex := match(m.keys[i], m.query)
+		switch {
+		case ok && ex:
+			exact = append(exact, i)
+		case ok:
+			fuzzy = append(fuzzy, i)
+		}
+	}
+	m.visible = append(exact, fuzzy...)
+	m.shown = make(map[int]bool, len(m.visible))
+	for _, i := range m.visible {
+		m.shown[i] = true
+	}
+
+	m.cursor = 0
+	for pos, i := range m.visible {
+		if i == cur {
+			m.cursor = pos
+			break
+		}
+	}
+	if m.roots != nil {
+		m.flattenTree()
+	}
+}
+
+// current is the item index under the cursor, or -1 (also on a group row
+// of the tree view).
+func (m *model) current() int {
+	if m.tree {
+		if n := m.currentNode(); n != nil {
+			return n.item
+		}
+		return -1
+	}
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return -1
+	}
+	return m.visible[m.cursor]
+}
+
+func (m *model) move(delta int) {
+	if m.tree {
+		m.tcursor = max(0, min(len(m.rows)-1, m.tcursor+delta))
+		return
+	}
+	m.cursor = max(0, min(len(m.visible)-1, m.cursor+delta))
+}
+
+// toggleTree switches between the flat list and the tree view, keeping
+// the cursor on the same item (or, in the tree, its nearest shown group).
+func (m *model) toggleTree() {
+	cur := m.current()
+	if m.roots == nil {
+		m.roots = buildTree(m.items, m.order, nil)
+		m.flattenTree()
+	}
+	m.tree = !m.tree
+	if cur < 0 {
+		return
+	}
+	if m.tree {
+		for pos, n := range m.rows {
+			if slices.Contains(n.leaves, cur) {
+				m.tcursor = pos
+			}
+		}
+		return
+	}
+	if pos := slices.Index(m.visible, cur); pos >= 0 {
+		m.cursor = pos
+	}
+}
+
+func (m *model) setVisible(v bool) {
+	for _, i := range m.visible {
+		m.selected[i] = v
+	}
+}
+
+// toggleGroup selects every visible row sharing the current row's group
+// key, or clears them when all are already selected.
+func (m *model) toggleGroup(key func(ListItem) string) {
+	cur := m.current()
+	if cur < 0 {
+		return
+	}
+	k := key(m.items[cur])
+	var rows []int
+	all := true
+	for _, i := range m.visible {
+		if key(m.items[i]) == k {
+			rows = append(rows, i)
+			all = all && m.selected[i]
+		}
+	}
+	for _, i := range rows {
+		m.selected[i] = !all
+	}
+}
+
+func (m *model) invertVisible() {
+	for _, i := range m.visible {
+		m.selected[i] = !m.selected[i]
+	}
+}
+
+// selectRange selects the visible rows between the anchor and the cursor.
+// Without a visible anchor the range starts at the cursor.
+func (m *model) selectRange() {
+	if m.tree {
+		m.selectTreeRange()
+		return
+	}
+	from := m.cursor
+	for pos, i := range m.visible {
+		if i == m.anchor {
+			from = pos
+			break
+		}
+	}
+	lo, hi := min(from, m.cursor), max(from, m.cursor)
+	for pos := lo; pos <= hi && pos < len(m.visible); pos++ {
+		m.selected[m.visible[pos]] = true
+	}
+}
 
-		key, err := readKey()
-		if err != nil {
-			return nil, err
+// update applies one key. done is true when the list was confirmed or
+// canceled (err is then support.ErrCanceled).
+func (m *model) update(key keyEvent) (done bool, err error) {
+	switch key.kind {
+	case keyUp:
+		m.move(-1)
+		return false, nil
+	case keyDown:
+		m.move(1)
+		return false, nil
+	case keyPgUp:
+		m.move(-10)
+		return false, nil
+	case keyPgDn:
+		m.move(10)
+		return false, nil
+	case keyHome:
+		m.move(-len(m.items))
+		return false, nil
+	case keyEnd:
+		m.move(len(m.visible))
+		return false, nil
+	case keyShiftUp, keyShiftDown:
+		if m.anchor < 0 || !slices.Contains(m.visible, m.anchor) {
+			m.anchor = m.current()
+		}
+		if key.kind == keyShiftUp {
+			m.move(-1)
+		} else {
+			m.move(1)
 		}
+		m.selectRange()
+		return false, nil
+	case keyLeft:
+		if m.tree {
+			m.collapse()
+		}
+		return false, nil
+	case keyRight:
+		if m.tree {
+			m.expand()
+		}
+		return false, nil
+	}
 
+	if key.kind == keyChar && key.ch == 0x03 { // Ctrl-C (no SIGINT in raw mode)
+		return true, support.ErrCanceled
+	}
+
+	if m.searching {
 		switch key.kind {
-		case keyUp:
-			cursor = max(0, cursor-1)
-		case keyDown:
-			cursor = min(len(items)-1, cursor+1)
-		case keyPgUp:
-			cursor = max(0, cursor-10)
-		case keyPgDn:
-			cursor = min(len(items)-1, cursor+10)
-		case keyHome:
-			cursor = 0
-		case keyEnd:
-			cursor = len(items) - 1
+		case keyEnter:
+			m.searching = false
+		case keyEsc:
+			m.searching = false
+			m.query = ""
+			m.applyFilter()
+		case keyBackspace:
+			if r := []rune(m.query); len(r) > 0 {
+				m.query = string(r[:len(r)-1])
+				m.applyFilter()
+			}
 		case keySpace:
-			selected[cursor] = !selected[cursor]
+			m.query += " "
 		case keyChar:
-			if key.ch == 'a' || key.ch == 'A' {
-				selected = map[int]bool{}
-				for i := 0; i < len(items); i++ {
-					selected[i] = true
-				}
-			} else if key.ch == 'n' || key.ch == 'N' {
-				selected = map[int]bool{}
-			} else if key.ch == 'q' || key.ch == 'Q' {
-				return nil, support.ErrCanceled
+			switch {
+			case key.ch == 0x15: // Ctrl-U
+				m.query = ""
+				m.applyFilter()
+			case key.ch >= ' ':
+				m.query += string(key.ch)
+				m.applyFilter()
+			}
+		}
+		return false, nil
+	}
 
+	switch key.kind {
+	case keySpace:
+		if m.tree {
+			m.toggleNode()
+		} else if i := m.current(); i >= 0 {
+			m.selected[i] = !m.selected[i]
+			m.anchor = i
+		}
+	case keyChar:
+		switch key.ch {
+		case 'a', 'A':
+			m.setVisible(true)
+		case 'n', 'N':
+			m.setVisible(false)
+		case 'v', 'V':
+			m.invertVisible()
+		case 'b', 'B':
+			m.toggleGroup(func(it ListItem) string { return it.Bundle })
+		case 'i', 'I':
+			m.toggleGroup(func(it ListItem) string { return it.Inf })
+		case 'o', 'O':
+			m.toggleGroup(func(it ListItem) string { return it.OS })
+		case 'r', 'R':
+			m.selectRange()
+		case 'd', 'D':
+			m.showDetails = !m.showDetails
+		case 't', 'T':
+			m.toggleTree()
+		case 's':
+			if len(m.columns) > 0 {
+				// next column, after the last one back to the original order
+				m.sortBy((m.sortCol+2)%(len(m.columns)+1)-1, m.sortDesc)
 			}
-		case keyEsc:
-			return nil, support.ErrCanceled
-		case keyEnter:
-			if len(selected) == 0 {
-				continue
+		case 'S':
+			m.sortBy(m.sortCol, !m.sortDesc)
+		case '+':
+			if m.tree {
+				m.setCollapsed(false)
 			}
-			idxs := make([]int, 0, len(selected))
-			for i, v := range selected {
-				if v {
-					idxs = append(idxs, i)
-				}
+		case '-':
+			if m.tree {
+				m.setCollapsed(true)
 			}
-			sort.Ints(idxs)
-			return idxs, nil
+		case '/':
+			m.searching = true
+		case 'c', 'C':
+			m.query = ""
+			m.applyFilter()
+		case 'q', 'Q':
+			return true, support.ErrCanceled
 		}
+	case keyEsc:
+		if m.query != "" {
+			m.query = ""
+			m.applyFilter()
+			return false, nil
+		}
+		return true, support.ErrCanceled
+	case keyEnter:
+		if len(m.result()) == 0 {
+			return false, nil
+		}
+		return true, nil
 	}
+	return false, nil
 }
 
-func draw(title string, legend []Legend, items []ListItem, selected map[int]bool, cursor int, top *int) {
-	fmt.Print(ClearScreen())
+// result is the selected item indexes in ascending order, including rows
+// hidden by the filter.
+func (m *model) result() []int {
+	idxs := make([]int, 0, len(m.selected))
+	for i, v := range m.selected {
+		if v {
+			idxs = append(idxs, i)
+		}
+	}
+	sort.Ints(idxs)
+	return idxs
+}
 
-	width, height := format.TermSizeBestEffort()
+// render returns the screen as lines (with colour codes) for a terminal of
+// the given size.
+func (m *model) render(width, height int) []string {
+	// the detail pane wraps to the real width: what the screen clips there
+	// would be lost
+	details := m.renderDetails(width)
 	if width < 60 {
 		width = 60
 	}
 	if height < 12 {
 		height = 12
 	}
+	var lines []string
+	title := m.title
+	if len(m.columns) > 0 {
+		title += "  |  排序: " + m.sortLabel()
+	}
+	lines = append(lines, truncCells(title, width))
 
-	fmt.Println(truncRunes(title, width))
-
-	if len(legend) > 1 {
-		fmt.Print("Bundles: ")
-		for i, l := range legend {
-			fmt.Print(Fg(l.Color))
+	if len(m.legend) > 1 {
+		var sb strings.Builder
+		sb.WriteString("Bundles: ")
+		for i, l := range m.legend {
 			infHint := ""
 			if len(l.SampleInfs) > 0 {
 				infHint = " (" + strings.Join(l.SampleInfs, ", ") + ")"
 			}
-			fmt.Print(l.Tag + ":" + support.Itoa(l.ItemCount) + infHint)
-			fmt.Print(Reset())
-			if i != len(legend)-1 {
-				fmt.Print("  ")
+			count := support.Itoa(l.ItemCount)
+			if l.Bundle != "" {
+				count = fmt.Sprintf("%d/%d", m.selectedIn(l.Bundle), l.ItemCount)
+			}
+			sb.WriteString(Fg(l.Color) + l.Tag + ":" + count + infHint + Reset())
+			if i != len(m.legend)-1 {
+				sb.WriteString("  ")
 			}
 		}
-		fmt.Println()
+		lines = append(lines, sb.String())
 	}
 
-	fmt.Println(truncRunes("↑↓移动  PgUp/PgDn跳转  Home/End  Space勾选  a全选  n清空  Enter确认  q退出", width))
-	fmt.Println(strings.Repeat("-", min(width, 120)))
-
-	viewH := height - 6
-	if len(legend) <= 1 {
-		viewH += 1
+	if m.searching {
+		lines = append(lines, truncCells("输入关键字过滤（空格分隔多个词，支持模糊匹配）  ↑↓移动  Backspace删除  Ctrl-U清空  Enter完成  Esc取消", width))
+	} else if m.tree {
+		lines = append(lines, truncCells("树形视图  ↑↓移动  ←折叠/上级  →展开  +全部展开  -全部折叠  Space勾选节点及其下全部  t列表视图", width))
+		lines = append(lines, truncCells("a全选可见  n清空可见  v反选  /搜索  c清除过滤  s排序列  S升/降序  d详情  Enter确认  q退出", width))
+	} else {
+		lines = append(lines, truncCells("↑↓移动  PgUp/PgDn跳转  Home/End  Space勾选  a全选可见  n清空可见  /搜索  c清除过滤  Enter确认  q退出", width))
+		lines = append(lines, truncCells("b整个Bundle  i整个INF  o整个OS  v反选  Shift+↑↓/r 从锚点(上次Space处)范围勾选  s/S排序  d详情  t树形视图", width))
+	}
+	if m.searching || m.query != "" {
+		cursor := ""
+		if m.searching {
+			cursor = "█"
+		}
+		lines = append(lines, truncCells(fmt.Sprintf("/%s%s  (%d/%d 匹配)", m.query, cursor, len(m.visible), len(m.items)), width))
 	}
+	lines = append(lines, strings.Repeat("-", min(width, 120)))
+
+	// rows fill what the header, detail pane and footer leave
+	viewH := height - len(lines) - len(details) - 2
 	if viewH < 3 {
 		viewH = 3
 	}
-
-	if cursor < *top {
-		*top = cursor
+	cursor, top, total := &m.cursor, &m.top, len(m.visible)
+	if m.tree {
+		cursor, top, total = &m.tcursor, &m.ttop, len(m.rows)
+	}
+	if *cursor < *top {
+		*top = *cursor
 	}
-	if cursor >= *top+viewH {
-		*top = cursor - viewH + 1
+	if *cursor >= *top+viewH {
+		*top = *cursor - viewH + 1
+	}
+	if *top > max(0, total-viewH) {
+		*top = max(0, total-viewH)
 	}
 
+	if total == 0 {
+		lines = append(lines, "(无匹配项)")
+	}
 	for row := 0; row < viewH; row++ {
-		idx := *top + row
-		if idx >= len(items) {
+		pos := *top + row
+		if pos >= total {
 			break
 		}
-
-		item := items[idx]
-		mark := "[ ]"
-		if selected[idx] {
-			mark = "[x]"
+		var line string
+		var color Color
+		if m.tree {
+			line, color = m.renderNode(m.rows[pos], width)
+		} else {
+			idx := m.visible[pos]
+			mark := "[ ]"
+			if m.selected[idx] {
+				mark = "[x]"
+			}
+			line, color = truncCells(fmt.Sprintf("%s %5d ", mark, idx+1)+m.items[idx].Text, width), m.items[idx].Color
 		}
-		prefix := fmt.Sprintf("%s %5d ", mark, idx+1)
-		line := truncRunes(prefix+item.Text, width)
-
-		if idx == cursor {
-			fmt.Print(BgDarkGray())
-			fmt.Print(Fg(item.Color))
-			fmt.Print(padRightRunes(line, width))
-			fmt.Print(Reset())
-			fmt.Println()
+		if pos == *cursor {
+			lines = append(lines, BgDarkGray()+Fg(color)+padRightCells(line, width)+Reset())
 		} else {
-			fmt.Print(Fg(item.Color))
-			fmt.Println(line)
-			fmt.Print(Reset())
+			lines = append(lines, Fg(color)+line+Reset())
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", min(width, 120)))
+	lines = append(lines, strings.Repeat("-", min(width, 120)))
+	lines = append(lines, details...)
 
-	selCount := 0
-	for _, v := range selected {
+	selCount, hidden := 0, 0
+	for i, v := range m.selected {
 		if v {
 			selCount++
+			if !m.shown[i] {
+				hidden++
+			}
 		}
 	}
-	fmt.Printf("已选 %d/%d | 当前 %d/%d\n", selCount, len(items), cursor+1, len(items))
+	status := fmt.Sprintf("已选 %d/%d | 当前 %d/%d", selCount, len(m.items), min(*cursor+1, total), total)
+	if hidden > 0 {
+		status += fmt.Sprintf(" | 其中 %d 项被过滤隐藏", hidden)
+	}
+	lines = append(lines, status)
+	return lines
 }
 
-func truncRunes(s string, width int) string {
-	r := []rune(s)
-	if len(r) <= width {
-		return s
+// renderDetails returns the detail pane for the row under the cursor:
+// every field in full, wrapped to the width, followed by a rule. It is
+// empty when the pane is off.
+func (m *model) renderDetails(width int) []string {
+	cur := m.current()
+	if !m.showDetails || cur < 0 {
+		return nil
+	}
+	it := m.items[cur]
+	fields := it.Details
+	if len(fields) == 0 {
+		fields = []Field{{Name: "Text", Value: support.Or(it.Filter, it.Text)}}
+	}
+	nameW := 0
+	for _, f := range fields {
+		nameW = max(nameW, cellWidth(f.Name))
 	}
-	if width <= 1 {
-		return string(r[:width])
+	var out []string
+	for _, f := range fields {
+		if support.IsBlank(f.Value) {
+			continue
+		}
+		prefix := padRightCells(f.Name+":", nameW+2)
+		indent := strings.Repeat(" ", cellWidth(prefix))
+		for i, part := range wrapCells(f.Value, width-cellWidth(prefix)) {
+			if i == 0 {
+				out = append(out, prefix+part)
+			} else {
+				out = append(out, indent+part)
+			}
+		}
 	}
-	return string(r[:width-1])
+	return append(out, strings.Repeat("-", min(width, 120)))
 }
 
-func padRightRunes(s string, width int) string {
-	r := []rune(s)
-	if len(r) >= width {
-		return s
+// selectedIn counts the selected items of a bundle.
+func (m *model) selectedIn(bundle string) int {
+	n := 0
+	for i, v := range m.selected {
+		if v && m.items[i].Bundle == bundle {
+			n++
+		}
 	}
-	return s + strings.Repeat(" ", width-len(r))
+	return n
 }
 
 func min(a, b int) int { if a < b { return a }; return b }
@@ -0,0 +1,353 @@
+package tui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+
+	"WU/internal/support"
+	"WU/internal/terminal"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+func TestMain(m *testing.M) {
+	// Box-drawing and arrow runes are one cell wide, whatever the locale
+	// of the machine running the tests.
+	runewidth.DefaultCondition.EastAsianWidth = false
+	os.Exit(m.Run())
+}
+
+const (
+	keyDownSeq  = "\x1b[B"
+	keyPgDnSeq  = "\x1b[6~"
+	keyPgUpSeq  = "\x1b[5~"
+	keyEndSeq   = "\x1b[F"
+	keyRightSeq = "\x1b[C"
+	keyHomeSeq  = "\x1b[H"
+	keyShiftDn  = "\x1b[1;2B"
+)
+
+func testItems(n int) ([]Legend, []ListItem) {
+	legend := []Legend{
+		{Bundle: "bundle-a", Tag: "B1", ItemCount: 0},
+		{Bundle: "bundle-b", Tag: "B2", ItemCount: 0},
+	}
+	items := make([]ListItem, n)
+	for i := range items {
+		b := i * len(legend) / n
+		legend[b].ItemCount++
+		text := fmt.Sprintf("%s net%d.inf | Win11 x64 | PCI\\VEN_8086&DEV_%04X", legend[b].Tag, i%3, i)
+		items[i] = ListItem{
+			Text:   text,
+			Filter: text,
+			Bundle: legend[b].Bundle,
+			Inf:    fmt.Sprintf("net%d.inf", i%3),
+			Tree:   []string{legend[b].Tag + " " + legend[b].Bundle, fmt.Sprintf("net%d.inf", i%3), "Windows 11 x64"},
+			Leaf:   fmt.Sprintf("PCI\\VEN_8086&DEV_%04X", i),
+			Columns: []Field{
+				{Name: "INF", Value: fmt.Sprintf("net%d.inf", i%3)},
+				{Name: "PnP ID", Value: fmt.Sprintf("PCI\\VEN_8086&DEV_%04X", i)},
+			},
+		}
+	}
+	return legend, items
+}
+
+// runScript drives the list on a virtual terminal with the given keys and
+// returns the result and every screen shown before a key was read.
+func runScript(t *testing.T, width, height, n int, keys string) ([]int, error, string) {
+	t.Helper()
+	legend, items := testItems(n)
+	return runItems(t, width, height, legend, items, keys)
+}
+
+func runItems(t *testing.T, width, height int, legend []Legend, items []ListItem, keys string) ([]int, error, string) {
+	t.Helper()
+	v := terminal.NewVirtual(width, height, keys)
+	got, err := runMultiSelect(v, "选择目标", legend, items)
+
+	var sb strings.Builder
+	for i, f := range v.Frames() {
+		fmt.Fprintf(&sb, "── frame %d ──\n%s", i+1, f)
+	}
+	fmt.Fprintf(&sb, "── after exit ──\n%s", v.Screen())
+	fmt.Fprintf(&sb, "── result ──\n%v %v\n", got, err)
+	return got, err, sb.String()
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s (go test -update rewrites it)\n%s", name, path, got)
+	}
+}
+
+func TestMultiSelectSelection(t *testing.T) {
+	got, err, frames := runScript(t, 72, 16, 4, " "+keyDownSeq+keyDownSeq+" \r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{0, 2}) {
+		t.Fatalf("result = %v, want [0 2]", got)
+	}
+	checkGolden(t, "selection", frames)
+}
+
+func TestMultiSelectPaging(t *testing.T) {
+	got, err, frames := runScript(t, 72, 16, 40, keyPgDnSeq+keyEndSeq+" "+keyPgUpSeq+" \r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{29, 39}) {
+		t.Fatalf("result = %v, want [29 39]", got)
+	}
+	checkGolden(t, "paging", frames)
+}
+
+func TestMultiSelectCancel(t *testing.T) {
+	_, err, frames := runScript(t, 72, 16, 6, "/0004\r q")
+	if !errors.Is(err, support.ErrCanceled) {
+		t.Fatalf("err = %v, want ErrCanceled", err)
+	}
+	checkGolden(t, "cancel", frames)
+
+	for _, tc := range []struct {
+		name, keys string
+		want       error
+	}{
+		{"ctrl-c", " \x03", support.ErrCanceled},
+		{"esc", "\x1b", support.ErrCanceled},
+		{"enter without selection, then input ends", "\r", io.EOF},
+	} {
+		if _, err, _ := runScript(t, 72, 16, 6, tc.keys); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestMultiSelectTree(t *testing.T) {
+	// switching views keeps the cursor on item 1, i.e. on B1/net0.inf; the
+	// INF is selected as a whole, expanded down to its first target, and
+	// that target is cleared again
+	keys := "t " + keyRightSeq + keyRightSeq + keyRightSeq + keyDownSeq + " t\r"
+	got, err, frames := runScript(t, 90, 24, 12, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{3}) {
+		t.Fatalf("result = %v, want [3]", got)
+	}
+	checkGolden(t, "tree", frames)
+}
+
+func TestBuildTree(t *testing.T) {
+	_, items := testItems(12)
+	roots := buildTree(items, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, nil)
+	if len(roots) != 2 || len(roots[0].children) != 3 || len(roots[0].leaves) != 6 {
+		t.Fatalf("roots = %d, B1 INFs = %d, B1 items = %d", len(roots), len(roots[0].children), len(roots[0].leaves))
+	}
+	os := roots[0].children[0].children[0]
+	if !slices.Equal(os.leaves, []int{0, 3}) || os.children[1].item != 3 || os.depth != 2 {
+		t.Fatalf("B1/net0.inf/OS = %+v", os)
+	}
+}
+
+func TestMultiSelectSort(t *testing.T) {
+	// INF ascending, then descending; the cursor and the selection follow
+	// item 1 as it moves; then PnP ID and back to the original order (kept
+	// descending)
+	got, err, frames := runScript(t, 72, 16, 6, "sS ss\r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{0}) {
+		t.Fatalf("result = %v, want [0]", got)
+	}
+	checkGolden(t, "sort", frames)
+}
+
+func TestSortRebuildsTreeKeepingCollapsedGroups(t *testing.T) {
+	_, items := testItems(12)
+	m := newModel("t", nil, items)
+	m.toggleTree()
+	m.update(keyEvent{kind: keyRight}) // expand B1/net0.inf under the cursor
+	m.sortBy(0, true)                  // INF descending
+	var labels []string
+	for _, n := range m.rows {
+		labels = append(labels, n.label)
+	}
+	want := []string{"B1 bundle-a", "net2.inf", "net1.inf", "net0.inf", "Windows 11 x64", "B2 bundle-b", "net2.inf", "net1.inf", "net0.inf"}
+	if !slices.Equal(labels, want) {
+		t.Fatalf("rows = %q, want %q", labels, want)
+	}
+	if n := m.currentNode(); n == nil || n.key != "\x00B1 bundle-a\x00net0.inf" {
+		t.Fatalf("cursor on %+v, want B1/net0.inf", n)
+	}
+}
+
+func TestMultiSelectClipsWideLegend(t *testing.T) {
+	// The legend is wider than the screen; when its counts change it must
+	// not spill onto the help line below, which is not redrawn.
+	legend, items := testItems(6)
+	legend[0].SampleInfs = []string{"intel_wifi_driver_package.inf", "intel_bluetooth_driver_package.inf", "intel_ethernet.inf"}
+	legend[1].SampleInfs = []string{"realtek_audio_driver_package.inf", "realtek_card_reader.inf"}
+	got, err, frames := runItems(t, 100, 24, legend, items, " \r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{0}) {
+		t.Fatalf("result = %v, want [0]", got)
+	}
+	checkGolden(t, "wide_legend", frames)
+}
+
+// press feeds keys to the model as the terminal would deliver them.
+func press(t *testing.T, m *model, keys string) {
+	t.Helper()
+	r := strings.NewReader(keys)
+	for {
+		k, err := readKey(r)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.update(k)
+	}
+}
+
+// groupModel is testItems(12) with an OS per item: B1 is items 0-5, B2
+// 6-11, the INF is netN.inf for N = i%3 and the OS alternates.
+func groupModel() *model {
+	legend, items := testItems(12)
+	for i := range items {
+		items[i].OS = []string{"Windows 10 x64", "Windows 11 x64"}[i%2]
+	}
+	return newModel("t", legend, items)
+}
+
+func TestGroupAndRangeKeys(t *testing.T) {
+	down := func(n int) string { return strings.Repeat(keyDownSeq, n) }
+	for _, tc := range []struct {
+		name, keys string
+		want       []int
+	}{
+		{"b selects the bundle", down(1) + "b", []int{0, 1, 2, 3, 4, 5}},
+		{"b completes a partly selected bundle", " " + down(7) + " " + keyHomeSeq + down(1) + "b", []int{0, 1, 2, 3, 4, 5, 7}},
+		{"b again clears it", down(1) + "bb", []int{}},
+		{"i selects the INF", down(1) + "i", []int{1, 4, 7, 10}},
+		{"o selects the OS", down(1) + "o", []int{1, 3, 5, 7, 9, 11}},
+		{"v inverts", " " + down(1) + " v", []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+		{"r selects from the anchor", down(2) + " " + down(3) + "r", []int{2, 3, 4, 5}},
+		{"r without an anchor selects the cursor row", down(3) + "r", []int{3}},
+		{"shift-down extends from the anchor", down(1) + " " + keyShiftDn + keyShiftDn, []int{1, 2, 3}},
+		{"shift-down without an anchor starts at the cursor", down(4) + keyShiftDn, []int{4, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := groupModel()
+			press(t, m, tc.keys)
+			if got := m.result(); !slices.Equal(got, tc.want) {
+				t.Errorf("result = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGroupAndRangeKeysUnderFilter(t *testing.T) {
+	// net1.inf shows items 1, 4, 7 and 10; keys only touch those, and rows
+	// selected before the filter stay selected
+	for _, tc := range []struct {
+		name, keys string
+		want       []int
+	}{
+		{"b", "/net1.inf\rb", []int{1, 4}},
+		{"o", "/net1.inf\r" + keyDownSeq + "o", []int{4, 10}},
+		{"v keeps hidden rows", " /net1.inf\rv", []int{0, 1, 4, 7, 10}},
+		{"r spans visible rows only", "/net1.inf\r " + keyDownSeq + keyDownSeq + "r", []int{1, 4, 7}},
+		{"hidden anchor restarts shift-range at the cursor", " /net1.inf\r" + keyDownSeq + keyShiftDn, []int{0, 4, 7}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := groupModel()
+			press(t, m, tc.keys)
+			if got := m.result(); !slices.Equal(got, tc.want) {
+				t.Errorf("result = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLegendCountsSelectedPerBundle(t *testing.T) {
+	m := groupModel()
+	press(t, m, keyDownSeq+"b"+strings.Repeat(keyDownSeq, 6)+" ")
+	legend := m.render(100, 24)[1]
+	for _, want := range []string{"B1:6/6", "B2:1/6"} {
+		if !strings.Contains(legend, want) {
+			t.Errorf("legend %q lacks %q", legend, want)
+		}
+	}
+}
+
+func TestMultiSelectDetails(t *testing.T) {
+	// d opens the pane for the row under the cursor: the long PnP ID wraps
+	// to the 59 usable columns, below the 60 the list is laid out for;
+	// the blank manufacturer is left out; on a group row of the tree view
+	// the pane is gone, and it is back in the list
+	legend, items := testItems(4)
+	for i := range items {
+		items[i].Details = []Field{
+			{Name: "INF", Value: items[i].Inf},
+			{Name: "Manufacturer", Value: ""},
+			{Name: "PnP ID", Value: fmt.Sprintf(`PCI\VEN_8086&DEV_%04X&SUBSYS_00008086&REV_01&CC_0280&ANOTHER_LONG_SEGMENT_%d`, i, i)},
+		}
+	}
+	items[0].Details[1].Value = "Intel"
+	keys := "d" + keyDownSeq + "tt \r"
+	got, err, frames := runItems(t, 60, 24, legend, items, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{1}) {
+		t.Fatalf("result = %v, want [1]", got)
+	}
+	checkGolden(t, "details", frames)
+}
+
+func TestMultiSelectKeepsHiddenSelection(t *testing.T) {
+	// item 0 is selected, then hidden by the search; a selects the visible
+	// net1.inf rows only, and after c the result still holds item 0
+	got, err, frames := runScript(t, 72, 16, 6, " /net1.inf\rac\r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{0, 1, 4}) {
+		t.Fatalf("result = %v, want [0 1 4]", got)
+	}
+	if !strings.Contains(frames, "其中 1 项被过滤隐藏") {
+		t.Error("hidden selection not reported while filtered")
+	}
+	checkGolden(t, "hidden_selection", frames)
+}
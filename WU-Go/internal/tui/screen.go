@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"io"
+	"strings"
+)
+
+// screen draws frames of lines, rewriting only the lines that changed
+// since the previous frame. A size change repaints everything.
+type screen struct {
+	out    io.Writer
+	prev   []string
+	width  int
+	height int
+}
+
+// draw shows lines on a terminal of the given size in a single write.
+// Lines are clipped to the width: the diff assumes each takes one row.
+func (s *screen) draw(lines []string, width, height int) {
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	clipped := make([]string, len(lines))
+	for i, l := range lines {
+		clipped[i] = truncCells(l, width)
+	}
+	lines = clipped
+	var b strings.Builder
+	if s.prev == nil || width != s.width || height != s.height {
+		b.WriteString(ClearScreen())
+		s.prev = nil
+	}
+	for i, l := range lines {
+		if i < len(s.prev) && s.prev[i] == l {
+			continue
+		}
+		b.WriteString(MoveTo(i) + l + Reset() + ClearLine())
+	}
+	for i := len(lines); i < len(s.prev); i++ {
+		b.WriteString(MoveTo(i) + ClearLine())
+	}
+	if b.Len() > 0 {
+		io.WriteString(s.out, b.String())
+	}
+	s.prev = append(s.prev[:0], lines...)
+	if s.prev == nil {
+		s.prev = []string{}
+	}
+	s.width, s.height = width, height
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreenRedrawsOnlyChangedLines(t *testing.T) {
+	var out strings.Builder
+	s := &screen{out: &out}
+
+	s.draw([]string{"title", "row 1", "row 2"}, 80, 24)
+	if !strings.HasPrefix(out.String(), ClearScreen()) {
+		t.Fatalf("first frame not a full repaint: %q", out.String())
+	}
+
+	out.Reset()
+	s.draw([]string{"title", "row 1*", "row 2"}, 80, 24)
+	if got, want := out.String(), MoveTo(1)+"row 1*"+Reset()+ClearLine(); got != want {
+		t.Fatalf("diff frame = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	s.draw([]string{"title", "row 1*", "row 2"}, 80, 24)
+	if out.Len() != 0 {
+		t.Fatalf("unchanged frame wrote %q", out.String())
+	}
+
+	out.Reset()
+	s.draw([]string{"title"}, 80, 24)
+	if got, want := out.String(), MoveTo(1)+ClearLine()+MoveTo(2)+ClearLine(); got != want {
+		t.Fatalf("shrunk frame = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	s.draw([]string{"title"}, 100, 24)
+	if !strings.HasPrefix(out.String(), ClearScreen()) {
+		t.Fatalf("resize did not repaint: %q", out.String())
+	}
+}
+
+func TestTruncCellsCountsWideRunesAndSkipsEscapes(t *testing.T) {
+	cases := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"abc", 3, "abc"},
+		{"abcd", 3, "ab"},
+		{"搜索过滤", 8, "搜索过滤"},
+		{"搜索过滤", 7, "搜索过"},
+		{"a搜索", 4, "a搜"},
+		{Fg(2) + "B1:0/3" + Reset() + " tail", 8, Fg(2) + "B1:0/3" + Reset() + " "},
+	}
+	for _, c := range cases {
+		if got := truncCells(c.in, c.width); got != c.want {
+			t.Errorf("truncCells(%q, %d) = %q, want %q", c.in, c.width, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"WU/internal/support"
+)
+
+// treeNode is a group (bundle, INF or OS) or an item of the tree view.
+type treeNode struct {
+	key       string // label path, stable across rebuilds
+	label     string
+	depth     int
+	item      int // item index of a leaf, -1 for a group
+	children  []*treeNode
+	leaves    []int // item indexes under the node, in list order
+	collapsed bool
+}
+
+// buildTree groups items by their Tree labels, in the given item order.
+// Groups take their collapsed state from prev (the tree being rebuilt);
+// new groups below the top level start collapsed.
+func buildTree(items []ListItem, order []int, prev []*treeNode) []*treeNode {
+	collapsed := map[string]bool{}
+	walkTree(prev, func(n *treeNode) { collapsed[n.key] = n.collapsed })
+
+	var roots []*treeNode
+	groups := map[string]*treeNode{}
+	for _, i := range order {
+		it := items[i]
+		siblings := &roots
+		var path []*treeNode
+		key := ""
+		for depth, label := range it.Tree {
+			key += "\x00" + label
+			n := groups[key]
+			if n == nil {
+				c, ok := collapsed[key]
+				if !ok {
+					c = depth > 0
+				}
+				n = &treeNode{key: key, label: label, depth: depth, item: -1, collapsed: c}
+				groups[key] = n
+				*siblings = append(*siblings, n)
+			}
+			path = append(path, n)
+			siblings = &n.children
+		}
+		leaf := &treeNode{key: key + "\x00#" + strconv.Itoa(i), label: support.Or(it.Leaf, it.Text), depth: len(it.Tree), item: i, leaves: []int{i}}
+		*siblings = append(*siblings, leaf)
+		for _, n := range path {
+			n.leaves = append(n.leaves, i)
+		}
+	}
+	return roots
+}
+
+// walkTree calls fn for every group, parents first.
+func walkTree(nodes []*treeNode, fn func(*treeNode)) {
+	for _, n := range nodes {
+		if n.item < 0 {
+			fn(n)
+			walkTree(n.children, fn)
+		}
+	}
+}
+
+// flattenTree lists the tree rows to show: expanded nodes with at least one
+// item passing the filter. While a query is active every group is shown
+// expanded, so the matches are in view. The cursor stays on its node, also
+// across a rebuild.
+func (m *model) flattenTree() {
+	cur := ""
+	if n := m.currentNode(); n != nil {
+		cur = n.key
+	}
+	m.rows = m.rows[:0]
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			if len(m.shownLeaves(n)) == 0 {
+				continue
+			}
+			m.rows = append(m.rows, n)
+			if !n.collapsed || m.query != "" {
+				walk(n.children)
+			}
+		}
+	}
+	walk(m.roots)
+
+	m.tcursor = 0
+	for pos, n := range m.rows {
+		if n.key == cur {
+			m.tcursor = pos
+			break
+		}
+	}
+}
+
+// shownLeaves are the items under n that pass the filter.
+func (m *model) shownLeaves(n *treeNode) []int {
+	var out []int
+	for _, i := range n.leaves {
+		if m.shown[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (m *model) currentNode() *treeNode {
+	if m.tcursor < 0 || m.tcursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.tcursor]
+}
+
+// toggleNode selects every shown item under the cursor's node, or clears
+// them when all are already selected.
+func (m *model) toggleNode() {
+	n := m.currentNode()
+	if n == nil {
+		return
+	}
+	leaves := m.shownLeaves(n)
+	all := true
+	for _, i := range leaves {
+		all = all && m.selected[i]
+	}
+	for _, i := range leaves {
+		m.selected[i] = !all
+	}
+	if n.item >= 0 {
+		m.anchor = n.item
+	}
+}
+
+// selectTreeRange selects the items on the rows between the anchor and
+// the cursor; a collapsed group in the range counts with all its items.
+func (m *model) selectTreeRange() {
+	from := m.tcursor
+	for pos, n := range m.rows {
+		if n.item >= 0 && n.item == m.anchor {
+			from = pos
+			break
+		}
+	}
+	lo, hi := min(from, m.tcursor), max(from, m.tcursor)
+	for pos := lo; pos <= hi && pos < len(m.rows); pos++ {
+		if n := m.rows[pos]; n.item >= 0 || n.collapsed {
+			for _, i := range m.shownLeaves(n) {
+				m.selected[i] = true
+			}
+		}
+	}
+}
+
+// expand opens the cursor's group, or steps into it when already open.
+func (m *model) expand() {
+	n := m.currentNode()
+	if n == nil || n.item >= 0 {
+		return
+	}
+	if n.collapsed {
+		n.collapsed = false
+		m.flattenTree()
+		return
+	}
+	m.move(1)
+}
+
+// collapse closes the cursor's group, or steps out to the parent.
+func (m *model) collapse() {
+	n := m.currentNode()
+	if n == nil {
+		return
+	}
+	if n.item < 0 && !n.collapsed && m.query == "" {
+		n.collapsed = true
+		m.flattenTree()
+		return
+	}
+	for pos := m.tcursor - 1; pos >= 0; pos-- {
+		if m.rows[pos].depth < n.depth {
+			m.tcursor = pos
+			return
+		}
+	}
+}
+
+// setCollapsed opens or closes every group.
+func (m *model) setCollapsed(v bool) {
+	walkTree(m.roots, func(n *treeNode) { n.collapsed = v })
+	m.flattenTree()
+}
+
+// renderNode is one tree row: a tri-state box, then the group with its
+// selected/shown count or the item label.
+func (m *model) renderNode(n *treeNode, width int) (string, Color) {
+	leaves := m.shownLeaves(n)
+	sel := 0
+	for _, i := range leaves {
+		if m.selected[i] {
+			sel++
+		}
+	}
+	mark := "[-]"
+	switch sel {
+	case 0:
+		mark = "[ ]"
+	case len(leaves):
+		mark = "[x]"
+	}
+	indent := strings.Repeat("  ", n.depth)
+	color := m.items[leaves[0]].Color
+	if n.item >= 0 {
+		return truncCells(fmt.Sprintf("%s %s  %s", mark, indent, n.label), width), color
+	}
+	arrow := "▾"
+	if n.collapsed && m.query == "" {
+		arrow = "▸"
+	}
+	return truncCells(fmt.Sprintf("%s %s%s %s  (%d/%d)", mark, indent, arrow, n.label, sel, len(leaves)), width), color
+}
@@ -1,15 +1,16 @@
 package ui
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
-	"golang.org/x/term"
+
+	"WU/internal/terminal"
 )
 
 // Colors
@@ -24,6 +25,9 @@ var (
 	red    = color.New(color.FgRed).SprintFunc()
 )
 
+// out is where everything is printed: the current terminal.
+func out() io.Writer { return terminal.Current() }
+
 const line = "────────────────────────────────────────────────"
 
 type StepCtx struct {
@@ -34,69 +38,76 @@ type StepCtx struct {
 
 // Banner prints the tool name and version in Wrangler style
 func Banner(tool, version string) {
-	fmt.Printf("\n %s %s %s\n", cyan("⛅️"), bold(tool), gray(version))
-	fmt.Println(gray(line))
-	fmt.Println("Hardware Dashboard API - Shipping Label Creator")
-	fmt.Println(" ")
-	fmt.Println("                             liuty24@lenovo.com")
-	fmt.Println(gray(line))
+	fmt.Fprintf(out(), "\n %s %s %s\n", cyan("⛅️"), bold(tool), gray(version))
+	fmt.Fprintln(out(), gray(line))
+	fmt.Fprintln(out(), "Hardware Dashboard API - Shipping Label Creator")
+	fmt.Fprintln(out(), " ")
+	fmt.Fprintln(out(), "                             liuty24@lenovo.com")
+	fmt.Fprintln(out(), gray(line))
 }
 
 // Section prints a section header
 func Section(step StepCtx) {
-	fmt.Println("")
-	fmt.Printf("%s %s %s\n", gray("╭"), orange(step.Title), gray(fmt.Sprintf("Step %d of %d", step.Current, step.Total)))
-	fmt.Println(gray("│"))
+	fmt.Fprintln(out(), "")
+	fmt.Fprintf(out(), "%s %s %s\n", gray("╭"), orange(step.Title), gray(fmt.Sprintf("Step %d of %d", step.Current, step.Total)))
+	fmt.Fprintln(out(), gray("│"))
 }
 
 // Item prints an item in the section
 // If value is empty, it just prints the label
 // If value is provided, it prints label then indented value
 func Item(label string, value ...string) {
-	fmt.Printf("%s %s\n", gray("├"), label)
+	fmt.Fprintf(out(), "%s %s\n", gray("├"), label)
 	for _, v := range value {
-		fmt.Printf("%s %s %s\n", gray("│"), gray("dir"), v) // "dir" is hardcoded in example but maybe we want generic?
+		fmt.Fprintf(out(), "%s %s %s\n", gray("│"), gray("dir"), v) // "dir" is hardcoded in example but maybe we want generic?
 		// keeping generic:
 		// fmt.Printf("%s %s\n", gray("│"), v)
 	}
-	fmt.Println(gray("│"))
+	fmt.Fprintln(out(), gray("│"))
 }
 
 // ItemValue prints a key-value pair
 func ItemValue(key, value string) {
-	fmt.Printf("%s %s\n", gray("├"), key)
-	fmt.Printf("%s %s %s\n", gray("│"), gray("value"), value)
-	fmt.Println(gray("│"))
+	fmt.Fprintf(out(), "%s %s\n", gray("├"), key)
+	fmt.Fprintf(out(), "%s %s %s\n", gray("│"), gray("value"), value)
+	fmt.Fprintln(out(), gray("│"))
 }
 
 // EndLine prints the closing line of a section
 func EndLine(label string) {
-	fmt.Printf("%s %s\n", gray("╰"), label)
+	fmt.Fprintf(out(), "%s %s\n", gray("╰"), label)
 }
 
 func Info(msg string) {
-	fmt.Printf("%s %s\n", blue("ℹ"), msg)
+	fmt.Fprintf(out(), "%s %s\n", blue("ℹ"), msg)
 }
 
 func Ok(msg string) {
-	fmt.Printf("%s %s\n", green("✅"), msg)
+	fmt.Fprintf(out(), "%s %s\n", green("✅"), msg)
 }
 
 func Warn(msg string) {
-	fmt.Printf("%s %s\n", yellow("⚠️"), msg)
+	fmt.Fprintf(out(), "%s %s\n", yellow("⚠️"), msg)
 }
 
 func Fail(msg string) {
-	fmt.Printf("%s %s\n", red("❌"), msg)
+	fmt.Fprintf(out(), "%s %s\n", red("❌"), msg)
 }
 
 func ErrorInside(msg string) {
-	fmt.Printf("%s %s %s\n", gray("│"), red("❌"), msg)
+	fmt.Fprintf(out(), "%s %s %s\n", gray("│"), red("❌"), msg)
 }
 
 // Spinner runs a task with a spinner
 func Spin(label string, task func() error) error {
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond) // Dots
+	switch terminal.Current().(type) {
+	case terminal.Std:
+	case terminal.Stderr:
+		spinner.WithWriterFile(os.Stderr)(s)
+	default:
+		s.Writer = out()
+	}
 	s.Suffix = " " + label
 	s.Color("cyan")
 	s.Start()
@@ -113,7 +124,7 @@ func Spin(label string, task func() error) error {
 // ├ In which directory do you want to create your application?
 // │ dir ./my-worker
 func Prompt(question string, def string) string {
-	fmt.Printf("%s %s\n", gray("├"), question)
+	fmt.Fprintf(out(), "%s %s\n", gray("├"), question)
 
 	prefix := "value"
 	// To match wrangler "dir ./my-worker" style where user types after "dir "
@@ -124,10 +135,9 @@ func Prompt(question string, def string) string {
 		promptLine = fmt.Sprintf("%s %s [%s] ", gray("│"), gray(prefix), def)
 	}
 
-	fmt.Print(promptLine)
+	fmt.Fprint(out(), promptLine)
 
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
+	input, _ := terminal.ReadLine(terminal.Current())
 	input = strings.TrimSpace(input)
 
 	if input == "" {
@@ -136,40 +146,68 @@ func Prompt(question string, def string) string {
 
 	// Print the vertical line to close the look if we want, but usually prompt ends the interaction for that line.
 	// But in Wrangler example, it continues.
-	fmt.Println(gray("│"))
+	fmt.Fprintln(out(), gray("│"))
 
 	return input
 }
 
+// PromptMoreLines continues a prompt for a multi-line paste and returns
+// the lines joined with newlines. Pasted text may contain blank lines, so
+// a blank line only ends the paste once complete reports the text so far
+// as whole (nil: always); two blank lines in a row, or the end of input,
+// always end it.
+func PromptMoreLines(hint string, complete func(text string) bool) string {
+	fmt.Fprintf(out(), "%s %s\n", gray("│"), gray(hint))
+	var lines []string
+	blanks := 0
+	for {
+		line, err := terminal.ReadLine(terminal.Current())
+		if strings.TrimSpace(line) == "" {
+			blanks++
+			text := strings.TrimSpace(strings.Join(lines, "\n"))
+			if err != nil || blanks >= 2 || complete == nil || complete(text) {
+				break
+			}
+		} else {
+			blanks = 0
+		}
+		lines = append(lines, line)
+		if err != nil {
+			break
+		}
+	}
+	fmt.Fprintln(out(), gray("│"))
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
 func PromptSecret(question string) string {
-	fmt.Printf("%s %s\n", gray("├"), question)
-	fmt.Printf("%s %s ", gray("│"), gray("secret"))
+	fmt.Fprintf(out(), "%s %s\n", gray("├"), question)
+	fmt.Fprintf(out(), "%s %s ", gray("│"), gray("secret"))
 
-	bytePassword, err := term.ReadPassword(int(os.Stdin.Fd()))
-	fmt.Println() // Newline after password input
-	fmt.Println(gray("│"))
+	password, err := terminal.Current().ReadSecret()
+	fmt.Fprintln(out()) // Newline after password input
+	fmt.Fprintln(out(), gray("│"))
 
 	if err != nil {
 		return ""
 	}
-	return string(bytePassword)
+	return password
 }
 
 func PromptYesNo(question string, def bool) bool {
-	fmt.Printf("%s %s\n", gray("├"), question)
+	fmt.Fprintf(out(), "%s %s\n", gray("├"), question)
 
 	yv := "y/N"
 	if def {
 		yv = "Y/n"
 	}
 
-	fmt.Printf("%s %s (%s) ", gray("│"), gray("confirm"), yv)
+	fmt.Fprintf(out(), "%s %s (%s) ", gray("│"), gray("confirm"), yv)
 
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
+	input, _ := terminal.ReadLine(terminal.Current())
 	input = strings.ToLower(strings.TrimSpace(input))
 
-	fmt.Println(gray("│"))
+	fmt.Fprintln(out(), gray("│"))
 
 	if input == "" {
 		return def
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"WU/internal/terminal"
+)
+
+func useVirtual(t *testing.T, keys string) *terminal.Virtual {
+	t.Helper()
+	color.NoColor = true
+	v := terminal.NewVirtual(60, 20, keys)
+	t.Cleanup(terminal.Use(v))
+	return v
+}
+
+func TestPromptsReadScriptedAnswers(t *testing.T) {
+	v := useVirtual(t, "my-label\r\n\nn\n{guid-1}\n{guid-2}\n\n")
+
+	if got := Prompt("Label name", "x"); got != "my-label" {
+		t.Fatalf("Prompt = %q", got)
+	}
+	if got := Prompt("Destination", "windowsUpdate"); got != "windowsUpdate" {
+		t.Fatalf("Prompt default = %q", got)
+	}
+	if PromptYesNo("Continue?", true) {
+		t.Fatal("PromptYesNo = true for n")
+	}
+	if got := PromptMoreLines("paste, end with an empty line", nil); got != "{guid-1}\n{guid-2}" {
+		t.Fatalf("PromptMoreLines = %q", got)
+	}
+
+	want := "├ Label name\n" +
+		"│ value [x] my-label\n" +
+		"│\n" +
+		"├ Destination\n" +
+		"│ value [windowsUpdate]\n" +
+		"├ Continue?\n" +
+		"│ confirm (Y/n) n\n" +
+		"│\n" +
+		"│ paste, end with an empty line\n" +
+		"{guid-1}\n" +
+		"{guid-2}\n" +
+		"\n" +
+		"│\n"
+	if got := v.Screen(); got != want {
+		t.Fatalf("screen:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPromptYesNoDefaultAtEndOfInput(t *testing.T) {
+	useVirtual(t, "")
+	if !PromptYesNo("Continue?", true) {
+		t.Fatal("PromptYesNo ignored the default when input ended")
+	}
+}
+
+func TestPromptMoreLinesKeepsBlankLinesUntilComplete(t *testing.T) {
+	useVirtual(t, "\nComputer Information\n\nHardware IDs\n{guid-1}\n\nnext answer\n")
+	hasGUID := func(text string) bool { return strings.Contains(text, "{") }
+	want := "Computer Information\n\nHardware IDs\n{guid-1}"
+	if got := PromptMoreLines("paste", hasGUID); got != want {
+		t.Fatalf("PromptMoreLines = %q, want %q", got, want)
+	}
+	if got := Prompt("Next", ""); got != "next answer" {
+		t.Fatalf("next Prompt = %q, the paste read too far", got)
+	}
+}
+
+func TestPromptMoreLinesEndsOnTwoBlankLines(t *testing.T) {
+	useVirtual(t, "no guids here\n\n\n")
+	if got := PromptMoreLines("paste", func(string) bool { return false }); got != "no guids here" {
+		t.Fatalf("PromptMoreLines = %q", got)
+	}
+}
@@ -12,6 +12,7 @@ var guidCanonicalRegex = regexp.MustCompile(`^\{?[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[
 func NormalizeCHIDsRequired(input []string) ([]string, error) {
 	out := []string{}
 	seen := map[string]bool{}
+	var bad []string
 
 	for _, raw := range input {
 		s := strings.TrimSpace(raw)
@@ -19,7 +20,8 @@ func NormalizeCHIDsRequired(input []string) ([]string, error) {
 			continue
 		}
 		if !guidCanonicalRegex.MatchString(s) {
-			return nil, support.NewAPIError("CHID 不是合法 GUID（需 8-4-4-4-12 且带连字符）: " + s)
+			bad = append(bad, "CHID 不是合法 GUID（需 8-4-4-4-12 且带连字符）: "+s)
+			continue
 		}
 		core := s
 		if strings.HasPrefix(core, "{") && strings.HasSuffix(core, "}") && len(core) > 2 {
@@ -32,6 +34,10 @@ func NormalizeCHIDsRequired(input []string) ([]string, error) {
 		}
 	}
 
+	// Report every bad value at once rather than one per attempt.
+	if len(bad) > 0 {
+		return nil, support.NewAPIError(strings.Join(bad, "\n"))
+	}
 	if len(out) == 0 {
 		return nil, support.NewAPIError("至少需要 1 个 CHID。")
 	}
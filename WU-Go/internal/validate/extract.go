@@ -0,0 +1,139 @@
+package validate
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// RejectedCHID is input that could not be read as a CHID.
+type RejectedCHID struct {
+	Line   int
+	Text   string
+	Reason string
+}
+
+// ExtractedCHIDs is the result of ExtractCHIDs.
+type ExtractedCHIDs struct {
+	CHIDs      []string // normalized, first occurrence order
+	Duplicates int
+	Rejected   []RejectedCHID
+}
+
+// ExtractCHIDs reads CHIDs from free text: comma/space separated lists,
+// one per line, or raw ComputerHardwareIds.exe output including its
+// "{guid}   <- Manufacturer + Family" comments and information lines.
+// Every valid GUID is kept (deduplicated); every line or token that looks
+// like it was meant as a CHID but is not one is reported with a reason.
+func ExtractCHIDs(text string) ExtractedCHIDs {
+	var out ExtractedCHIDs
+	seen := map[string]bool{}
+
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		content := line
+		if i := strings.Index(content, "<-"); i >= 0 {
+			content = content[:i]
+		}
+		content = strings.TrimSpace(content)
+		if ignorableCHIDLine(content) {
+			continue
+		}
+
+		tokens := strings.FieldsFunc(content, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t'
+		})
+		found := false
+		var bad []RejectedCHID
+		for _, tok := range tokens {
+			norm, reason := checkCHID(tok)
+			if reason == "" {
+				found = true
+				if seen[norm] {
+					out.Duplicates++
+					continue
+				}
+				seen[norm] = true
+				out.CHIDs = append(out.CHIDs, norm)
+				continue
+			}
+			if looksLikeCHID(tok) {
+				bad = append(bad, RejectedCHID{Line: n, Text: tok, Reason: reason})
+			}
+		}
+		if !found && len(bad) == 0 {
+			bad = append(bad, RejectedCHID{Line: n, Text: strings.TrimSpace(line), Reason: "未找到 CHID"})
+		}
+		out.Rejected = append(out.Rejected, bad...)
+	}
+	return out
+}
+
+// ignorableCHIDLine reports blank lines and the non-GUID lines of
+// ComputerHardwareIds.exe output (titles, rules, "Key: value").
+func ignorableCHIDLine(s string) bool {
+	switch {
+	case s == "":
+		return true
+	case strings.Trim(s, "-= ") == "":
+		return true
+	case strings.EqualFold(s, "Computer Information"), strings.EqualFold(s, "Hardware IDs"):
+		return true
+	case strings.HasPrefix(strings.ToLower(s), "using the bios to gather information"):
+		return true
+	case strings.Contains(s, ":") && !strings.ContainsAny(s, "{}"):
+		return true
+	}
+	return false
+}
+
+// looksLikeCHID separates mistyped GUIDs from unrelated words on the same
+// line.
+func looksLikeCHID(s string) bool {
+	if strings.ContainsAny(s, "{}-") {
+		return true
+	}
+	hex := 0
+	for _, r := range s {
+		if isHex(r) {
+			hex++
+		}
+	}
+	return len(s) >= 16 && hex*4 >= len(s)*3
+}
+
+// checkCHID returns the normalized CHID, or why s is not one.
+func checkCHID(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	core := s
+	open, close := strings.HasPrefix(core, "{"), strings.HasSuffix(core, "}")
+	if open != close {
+		return "", "花括号不成对"
+	}
+	if open {
+		core = core[1 : len(core)-1]
+	}
+	if guidCanonicalRegex.MatchString(core) {
+		return strings.ToLower(core), ""
+	}
+	for _, r := range core {
+		if !isHex(r) && r != '-' {
+			return "", fmt.Sprintf("含非十六进制字符 %q", r)
+		}
+	}
+	if !strings.Contains(core, "-") && len(core) == 32 {
+		return "", "缺少连字符（需 8-4-4-4-12）"
+	}
+	groups := strings.Split(core, "-")
+	lens := make([]string, 0, len(groups))
+	for _, g := range groups {
+		lens = append(lens, fmt.Sprint(len(g)))
+	}
+	return "", "分组长度为 " + strings.Join(lens, "-") + "（需 8-4-4-4-12）"
+}
+
+func isHex(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractCHIDsFromComputerHardwareIds(t *testing.T) {
+	text := `Using the BIOS to gather information
+
+Computer Information
+--------------------
+BIOS Vendor: LENOVO
+Manufacturer: LENOVO
+
+Hardware IDs
+------------
+{E093D715-70F7-51F4-B6C8-B4A7E31DEF85}   <- Manufacturer + EnclosureType
+{6de5d951-d755-576b-bd09-c5cf66b27234}   <-- Manufacturer
+{6de5d951-d755-576b-bd09-c5cf66b27234}   <- Manufacturer
+`
+	got := ExtractCHIDs(text)
+	want := "e093d715-70f7-51f4-b6c8-b4a7e31def85,6de5d951-d755-576b-bd09-c5cf66b27234"
+	if strings.Join(got.CHIDs, ",") != want {
+		t.Fatalf("CHIDs = %v", got.CHIDs)
+	}
+	if got.Duplicates != 1 || len(got.Rejected) != 0 {
+		t.Fatalf("duplicates = %d, rejected = %+v", got.Duplicates, got.Rejected)
+	}
+}
+
+func TestExtractCHIDsRejected(t *testing.T) {
+	text := "6de5d951-d755-576b-bd09-c5cf66b27234, 6de5d951d755576bbd09c5cf66b27234\n" +
+		"{e093d715-70f7-51f4-b6c8-b4a7e31def85\n" +
+		"e093d715-70f7-51f4-b6c8-b4a7e31def8\n" +
+		"e093d715-70f7-51f4-b6c8-b4a7e31defzz\n" +
+		"see the wiki\n"
+	got := ExtractCHIDs(text)
+	if len(got.CHIDs) != 1 {
+		t.Fatalf("CHIDs = %v", got.CHIDs)
+	}
+	wants := []struct {
+		line   int
+		reason string
+	}{
+		{1, "缺少连字符"},
+		{2, "花括号不成对"},
+		{3, "分组长度为 8-4-4-4-11"},
+		{4, "含非十六进制字符 'z'"},
+		{5, "未找到 CHID"},
+	}
+	if len(got.Rejected) != len(wants) {
+		t.Fatalf("rejected = %+v", got.Rejected)
+	}
+	for i, w := range wants {
+		r := got.Rejected[i]
+		if r.Line != w.line || !strings.Contains(r.Reason, w.reason) {
+			t.Errorf("[%d] = %+v, want line %d %q", i, r, w.line, w.reason)
+		}
+	}
+}
+
+func TestNormalizeCHIDsRequiredReportsAll(t *testing.T) {
+	_, err := NormalizeCHIDsRequired([]string{"bad-1", "6de5d951-d755-576b-bd09-c5cf66b27234", "bad-2"})
+	if err == nil || !strings.Contains(err.Error(), "bad-1") || !strings.Contains(err.Error(), "bad-2") {
+		t.Fatalf("err = %v", err)
+	}
+}
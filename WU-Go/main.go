@@ -13,5 +13,5 @@ func main() {
 		cli.PrintErr(err)
 		os.Exit(2)
 	}
-	os.Exit(app.Run(opt))
+	os.Exit(app.Dispatch(opt))
 }